@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"time"
+
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// Most commits changing a file searched for when its findings' secrets were
+// introduced. Secrets older than that, or than a shallow clone's history,
+// are reported as introduced by the oldest commit searched.
+const maxAgeCommits = 5000
+
+// annotateSecretAge sets the commit and time that introduced each finding's
+// secret in files, found in the checkout of r's HEAD in fs, and how many days
+// it has been exposed as of now. A secret is introduced by the oldest commit
+// in the unbroken run of commits changing its file, back from HEAD, that
+// contain it. Files found in past commits are skipped.
+func annotateSecretAge(r *git.Repository, fs billy.Filesystem, files []SensitiveFile, now time.Time) error {
+	for i := range files {
+		f := &files[i]
+		if f.Commit != "" {
+			continue
+		}
+		data, err := readFSFile(fs, f.Path)
+		if err != nil {
+			return err
+		}
+		introduced, err := secretsIntroduced(r, filepath.ToSlash(f.Path), data, f.Positions)
+		if err != nil {
+			return err
+		}
+		for j, c := range introduced {
+			if c == nil {
+				continue
+			}
+			when := c.Committer.When.UTC()
+			f.Positions[j].IntroducedCommit = c.Hash.String()
+			f.Positions[j].IntroducedAt = &when
+			f.Positions[j].ExposureDays = int(now.Sub(when).Hours() / 24)
+		}
+	}
+	return nil
+}
+
+// secretsIntroduced returns the commit introducing the secret at each of
+// positions in data, the contents of the file at relPath at HEAD, or nil if
+// the secret is not committed.
+func secretsIntroduced(r *git.Repository, relPath string, data []byte, positions []SensitivePos) ([]*object.Commit, error) {
+	iter, err := r.Log(&git.LogOptions{FileName: &relPath})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	introduced := make([]*object.Commit, len(positions))
+	done := make([]bool, len(positions))
+	pending, n := len(positions), 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		var contents []byte
+		if file, err := c.File(relPath); err == nil {
+			s, err := file.Contents()
+			if err != nil {
+				return err
+			}
+			contents = []byte(s)
+		}
+		for j, pos := range positions {
+			if done[j] {
+				continue
+			}
+			if bytes.Contains(contents, data[pos.Start:pos.End]) {
+				introduced[j] = c
+			} else {
+				done[j] = true
+				pending--
+			}
+		}
+		if n++; pending == 0 || n == maxAgeCommits {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return introduced, err
+}
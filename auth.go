@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deviceCodeURL  = "https://github.com/login/device/code"
+	accessTokenURL = "https://github.com/login/oauth/access_token"
+	deviceGrant    = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+var (
+	// Client ID of the OAuth App to authorize with the device flow.
+	oauthClientID string
+	// Space-delimited scopes requested during device flow login.
+	oauthScopes string
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage GitHub credentials",
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authorize skrt with GitHub using the OAuth device flow",
+	Run: func(cmd *cobra.Command, args []string) {
+		if oauthClientID == "" {
+			logrus.Error("auth login: --client-id is required")
+			os.Exit(1)
+		}
+
+		token, err := deviceFlowLogin(context.Background(), oauthClientID, oauthScopes)
+		if err != nil {
+			logrus.Error("auth login: ", err)
+			os.Exit(1)
+		}
+		path, err := storeToken(token)
+		if err != nil {
+			logrus.Error("auth login: ", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Logged in. Token stored in %s.\n", path)
+	},
+}
+
+func init() {
+	authLoginCmd.Flags().StringVar(&oauthClientID, "client-id", "", "Client ID of the GitHub OAuth App to authorize.")
+	authLoginCmd.Flags().StringVar(&oauthScopes, "scopes", "repo read:org", "Space-delimited OAuth scopes to request.")
+	authCmd.AddCommand(authLoginCmd)
+}
+
+type deviceCodeResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// deviceFlowLogin runs the GitHub OAuth device flow: it requests a user code,
+// asks the user to enter it in a browser, then polls until the user has
+// authorized the app and returns the resulting access token.
+func deviceFlowLogin(ctx context.Context, clientID, scopes string) (string, error) {
+	var dc deviceCodeResponse
+	err := postForm(ctx, deviceCodeURL, url.Values{
+		"client_id": {clientID},
+		"scope":     {scopes},
+	}, &dc)
+	if err != nil {
+		return "", fmt.Errorf("request device code: %v", err)
+	}
+	if dc.DeviceCode == "" {
+		return "", errors.New("request device code: empty response")
+	}
+
+	fmt.Printf("Open %s and enter code %s\n", dc.VerificationURI, dc.UserCode)
+
+	interval := time.Duration(dc.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		var at accessTokenResponse
+		err := postForm(ctx, accessTokenURL, url.Values{
+			"client_id":   {clientID},
+			"device_code": {dc.DeviceCode},
+			"grant_type":  {deviceGrant},
+		}, &at)
+		if err != nil {
+			return "", fmt.Errorf("poll access token: %v", err)
+		}
+
+		switch at.Error {
+		case "":
+			return at.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return "", fmt.Errorf("%s: %s", at.Error, at.Description)
+		}
+	}
+
+	return "", errors.New("device code expired before authorization")
+}
+
+// postForm POSTs form to u and decodes the JSON response into v.
+func postForm(ctx context.Context, u string, form url.Values, v interface{}) error {
+	req, err := http.NewRequest("POST", u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// tokenPath is where auth login stores the access token.
+func tokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "seekret", "token"), nil
+}
+
+// storeToken writes token to tokenPath, readable only by the current user.
+func storeToken(token string) (string, error) {
+	path, err := tokenPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, []byte(token+"\n"), 0600)
+}
+
+// readStoredToken returns the token stored by auth login, if any.
+func readStoredToken() (string, error) {
+	path, err := tokenPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Severity of findings verified to be live credentials.
+const severityVerified = "critical"
+
+var (
+	// STS endpoint key pairs are verified against.
+	stsEndpoint = "https://sts.amazonaws.com/"
+	// Client credential verification requests are sent with.
+	verifyClient = &http.Client{Timeout: 10 * time.Second}
+
+	// Long-term access key IDs. Temporary (ASIA) keys need a session token
+	// to verify.
+	awsKeyIDPattern  = regexp.MustCompile(`AKIA[0-9A-Z]{16}`)
+	awsSecretPattern = regexp.MustCompile(`[A-Za-z0-9/+]{40}$`)
+)
+
+// Most key ID and secret candidates paired per file, bounding the number of
+// verification requests a file full of keys can cause.
+const maxAWSCandidates = 4
+
+// verifyAWSPositions pairs AWS access key IDs with secret access keys found
+// in the same file and marks both Verified if sts:GetCallerIdentity accepts
+// the pair, setting each candidate's Verification state. Keys AWS refused
+// with every pairing tried are invalid. Only a signature derived from the
+// secret is sent, never the secret itself.
+func verifyAWSPositions(ctx context.Context, positions []SensitivePos, fileData []byte) {
+	var ids, secrets []int
+	for i, pos := range positions {
+		switch pos.Rule {
+		case "aws-access-key-id":
+			if len(ids) < maxAWSCandidates {
+				ids = append(ids, i)
+			}
+		case "aws-secret-access-key":
+			if len(secrets) < maxAWSCandidates {
+				secrets = append(secrets, i)
+			}
+		}
+	}
+
+	checked := make(map[int]bool)
+	failed := make(map[int]bool)
+	for _, s := range secrets {
+		secret := awsSecretPattern.Find(fileData[positions[s].Start:positions[s].End])
+		for _, id := range ids {
+			keyID := awsKeyIDPattern.Find(fileData[positions[id].Start:positions[id].End])
+			if secret == nil || keyID == nil {
+				continue
+			}
+			active, err := awsKeyActive(ctx, string(keyID), string(secret))
+			if err != nil {
+				logrus.Error("verifyAWSPositions: ", err)
+				failed[s], failed[id] = true, true
+				continue
+			}
+			checked[s], checked[id] = true, true
+			if active {
+				positions[s].Verified = true
+				positions[id].Verified = true
+				break
+			}
+		}
+	}
+
+	for _, i := range append(ids, secrets...) {
+		switch {
+		case positions[i].Verified:
+			positions[i].Verification = stateVerified
+		case checked[i] && !failed[i]:
+			positions[i].Verification = stateInvalid
+		default:
+			// Unpaired keys and failed checks.
+			positions[i].Verification = stateUnverified
+		}
+	}
+}
+
+// awsKeyActive calls sts:GetCallerIdentity signed with the key pair keyID
+// and secret, and reports whether AWS accepted it.
+func awsKeyActive(ctx context.Context, keyID, secret string) (bool, error) {
+	req, err := http.NewRequest("GET", stsEndpoint+"?Action=GetCallerIdentity&Version=2011-06-15", nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	signV4(req, nil, keyID, secret, "us-east-1", "sts", time.Now())
+
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusForbidden:
+		// Invalid, deactivated, and mismatched keys are all refused.
+		return false, nil
+	}
+	return false, fmt.Errorf("sts: unexpected status %s", resp.Status)
+}
+
+// signV4 signs req, which has body payload, with AWS Signature Version 4
+// for service in region at time now. Every header already set on req is
+// signed, along with Host and X-Amz-Date.
+func signV4(req *http.Request, payload []byte, keyID, secret, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// Query values must be escaped with %20 rather than +.
+	query := strings.Replace(req.URL.Query().Encode(), "+", "%20", -1)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		keyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Version of the findings bundle format written by findings export. Import
+// refuses bundles of other versions.
+const bundleVersion = 1
+
+var (
+	// Store findings are exported from or imported into.
+	bundleStoreSpec string
+	// Files holding the base64 Ed25519 keys bundles are signed and verified
+	// with.
+	bundlePrivateKeyFile string
+	bundlePublicKeyFile  string
+	// File bundles are written to. Defaults to stdout.
+	bundleOutputFile string
+	// Exported findings are limited to those matching bundleQuery.
+	bundleQuery FindingQuery
+)
+
+var findingsCmd = &cobra.Command{
+	Use:   "findings",
+	Short: "Move stored findings between environments in signed bundles",
+	Long: `Move stored findings between environments in signed bundles.
+
+Scans run in an isolated environment save findings to a local --store, then
+'findings export' writes them to a bundle signed with a private key. Elsewhere,
+'findings import' verifies the bundle with the matching public key and adds
+its findings to the central store, where they are triaged as usual. Bundles
+hold no matched data. Create a key pair with 'findings keygen'.`,
+}
+
+var findingsKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a key pair for signing and verifying bundles",
+	Run: func(cmd *cobra.Command, args []string) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			logrus.Error("findings keygen: ", err)
+			os.Exit(1)
+		}
+		if err := writeKeyFile(bundlePrivateKeyFile, priv, 0600); err != nil {
+			logrus.Error("findings keygen: ", err)
+			os.Exit(1)
+		}
+		if err := writeKeyFile(bundlePublicKeyFile, pub, 0644); err != nil {
+			logrus.Error("findings keygen: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var findingsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write stored findings to a signed bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := readKeyFile(bundlePrivateKeyFile, ed25519.PrivateKeySize)
+		if err != nil {
+			logrus.Error("findings export: ", err)
+			os.Exit(1)
+		}
+		store, err := openStore(bundleStoreSpec)
+		if err != nil {
+			logrus.Error("findings export: ", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		findings, err := store.QueryFindings(bundleQuery)
+		if err != nil {
+			logrus.Error("findings export: ", err)
+			os.Exit(1)
+		}
+		data, err := signBundle(findingsBundle{
+			Version:   bundleVersion,
+			CreatedAt: time.Now().UTC(),
+			Findings:  findings,
+		}, ed25519.PrivateKey(key))
+		if err != nil {
+			logrus.Error("findings export: ", err)
+			os.Exit(1)
+		}
+
+		if bundleOutputFile == "" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = ioutil.WriteFile(bundleOutputFile, data, 0600)
+		}
+		if err != nil {
+			logrus.Error("findings export: ", err)
+			os.Exit(1)
+		}
+		logrus.Infof("Exported %d findings.", len(findings))
+	},
+}
+
+var findingsImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Verify a bundle and add its findings to a store",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := readKeyFile(bundlePublicKeyFile, ed25519.PublicKeySize)
+		if err != nil {
+			logrus.Error("findings import: ", err)
+			os.Exit(1)
+		}
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			logrus.Error("findings import: ", err)
+			os.Exit(1)
+		}
+		b, err := verifyBundle(data, ed25519.PublicKey(key))
+		if err != nil {
+			logrus.Error("findings import: ", err)
+			os.Exit(1)
+		}
+
+		store, err := openStore(bundleStoreSpec)
+		if err != nil {
+			logrus.Error("findings import: ", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		// Findings already in the store keep their status, so triage done
+		// centrally is not undone by later imports.
+		for _, f := range b.Findings {
+			if err := store.SaveFinding(f); err != nil {
+				logrus.Error("findings import: ", err)
+				os.Exit(1)
+			}
+		}
+		logrus.Infof("Imported %d findings from a bundle created %s.", len(b.Findings), b.CreatedAt.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	findingsCmd.PersistentFlags().StringVar(&bundleStoreSpec, "store", "", "Store findings are exported from or imported into: sqlite:<path> or a postgres:// URL.")
+	findingsKeygenCmd.Flags().StringVar(&bundlePrivateKeyFile, "private-key", "bundle.key", "File the private key is written to, readable only by the current user.")
+	findingsKeygenCmd.Flags().StringVar(&bundlePublicKeyFile, "public-key", "bundle.pub", "File the public key is written to.")
+	findingsExportCmd.Flags().StringVar(&bundlePrivateKeyFile, "private-key", "bundle.key", "File of the private key bundles are signed with.")
+	findingsExportCmd.Flags().StringVarP(&bundleOutputFile, "output", "o", "", "File the bundle is written to. Defaults to stdout.")
+	findingsExportCmd.Flags().StringVar(&bundleQuery.Repo, "repo", "", "Only export findings in this repo, as owner/name.")
+	findingsExportCmd.Flags().StringVar(&bundleQuery.Rule, "rule", "", "Only export findings of this rule.")
+	findingsExportCmd.Flags().StringVar(&bundleQuery.Status, "status", "", "Only export findings with this status: open, resolved, or ignored.")
+	findingsImportCmd.Flags().StringVar(&bundlePublicKeyFile, "public-key", "bundle.pub", "File of the public key bundles are verified with.")
+	findingsCmd.AddCommand(findingsKeygenCmd)
+	findingsCmd.AddCommand(findingsExportCmd)
+	findingsCmd.AddCommand(findingsImportCmd)
+}
+
+// findingsBundle is the signed content of a bundle.
+type findingsBundle struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Findings  []StoredFinding `json:"findings"`
+}
+
+// signedBundle is a bundle as written: its content, exactly as signed, and
+// the base64 Ed25519 signature of the content.
+type signedBundle struct {
+	Bundle    json.RawMessage `json:"bundle"`
+	Signature string          `json:"signature"`
+}
+
+// signBundle returns b signed with key, in the format verifyBundle reads.
+func signBundle(b findingsBundle, key ed25519.PrivateKey) ([]byte, error) {
+	content, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	// Indenting would reformat the signed content, so the bundle is
+	// written compactly.
+	data, err := json.Marshal(signedBundle{
+		Bundle:    content,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// verifyBundle returns the bundle in data if it was signed with the private
+// key of key and is of the current version.
+func verifyBundle(data []byte, key ed25519.PublicKey) (*findingsBundle, error) {
+	var sb signedBundle
+	if err := json.Unmarshal(data, &sb); err != nil {
+		return nil, fmt.Errorf("read bundle: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(sb.Signature)
+	if err != nil || !ed25519.Verify(key, sb.Bundle, sig) {
+		return nil, errors.New("bundle signature is invalid")
+	}
+	var b findingsBundle
+	if err := json.Unmarshal(sb.Bundle, &b); err != nil {
+		return nil, fmt.Errorf("read bundle: %v", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("bundle version %d is not supported; want %d", b.Version, bundleVersion)
+	}
+	return &b, nil
+}
+
+// writeKeyFile writes key, base64-encoded, to a new file at path with mode
+// perm.
+func writeKeyFile(path string, key []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, base64.StdEncoding.EncodeToString(key)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readKeyFile reads a base64 key of size bytes from the file at path.
+func readKeyFile(path string, size int) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%s is not a key made by findings keygen", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/estroz/seekret/detection"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// canaryRule is the rule name of findings of canary tokens.
+const canaryRule = "canary"
+
+var (
+	// Number of canary tokens to generate.
+	canaryCount int
+	// Prefix making generated canaries recognizable.
+	canaryPrefix string
+	// File the hashes of generated canaries are appended to, and read from
+	// when monitoring.
+	canaryHashesFile string
+)
+
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Generate and monitor for canary credentials",
+}
+
+var canaryGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate canary tokens and record their hashes",
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := os.OpenFile(canaryHashesFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			logrus.Error("canary generate: ", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		for i := 0; i < canaryCount; i++ {
+			token, err := newCanaryToken(canaryPrefix)
+			if err != nil {
+				logrus.Error("canary generate: ", err)
+				os.Exit(1)
+			}
+			sum := sha256.Sum256([]byte(token))
+			if _, err := fmt.Fprintln(f, hex.EncodeToString(sum[:])); err != nil {
+				logrus.Error("canary generate: ", err)
+				os.Exit(1)
+			}
+			fmt.Println(token)
+		}
+	},
+}
+
+var canaryMonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Scan an organization for canary tokens and alert on any found",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if watchlist, err = loadWatchlist(canaryHashesFile); err != nil {
+			logrus.Error("canary monitor: ", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		client := newClient(ctx)
+		if err := preflight(ctx, client, orgName); err != nil {
+			logrus.Error("canary monitor: ", err)
+			os.Exit(1)
+		}
+
+		// Findings are relabeled in place, so all must stay in memory.
+		spillThreshold = 0
+		rw, err := newJSONLinesWriter("")
+		if err != nil {
+			logrus.Error("canary monitor: ", err)
+			os.Exit(1)
+		}
+		if err := CrawlOrg(ctx, client, orgName, &canaryAlertWriter{rw}); err != nil {
+			logrus.Error("canary monitor: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	canaryCmd.PersistentFlags().StringVar(&canaryHashesFile, "hashes-file", "canaries.sha256", "File of canary token hashes, in --watchlist format.")
+	canaryGenerateCmd.Flags().IntVar(&canaryCount, "count", 1, "Number of canary tokens to generate.")
+	canaryGenerateCmd.Flags().StringVar(&canaryPrefix, "prefix", "skrtcanary", "Prefix of generated canary tokens.")
+	canaryCmd.AddCommand(canaryGenerateCmd)
+	canaryCmd.AddCommand(canaryMonitorCmd)
+}
+
+// newCanaryToken returns prefix followed by 40 random base62 characters,
+// distinctive enough never to occur by chance.
+func newCanaryToken(prefix string) (string, error) {
+	b := make([]byte, 40)
+	max := big.NewInt(int64(len(detection.Base62Digits)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = detection.Base62Digits[n.Int64()]
+	}
+	return prefix + "_" + string(b), nil
+}
+
+// canaryAlertWriter relabels watchlist findings as canary findings and logs
+// an alert for each before passing results on.
+type canaryAlertWriter struct {
+	ResultWriter
+}
+
+func (w *canaryAlertWriter) WriteRepo(sr SensitiveRepo) error {
+	for _, file := range sr.Files {
+		for i := range file.Positions {
+			file.Positions[i].Rule = canaryRule
+			logrus.Warnf("CANARY TRIGGERED: repo %s file %s %s", sr.Name, file.Path, file.Commit)
+		}
+	}
+	return w.ResultWriter.WriteRepo(sr)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/estroz/seekret/detection"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Print a JSON description of the providers, detectors, rules, verifiers, and reporters this build supports",
+	Long: `Print a JSON description of the providers, detectors, rules, verifiers, and
+reporters this build supports, and the flag enabling each that is off by
+default, so tooling orchestrating scans can adapt to a given deployment.
+Rules of a --rules file and detectors of --plugin executables are included
+if given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := registerPlugins(plugins); err != nil {
+			logrus.Error("capabilities: ", err)
+			os.Exit(1)
+		}
+		if rulesFile != "" {
+			var err error
+			if customRules, err = loadRulesFile(rulesFile); err != nil {
+				logrus.Error("capabilities: load rules: ", err)
+				os.Exit(1)
+			}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(capabilities()); err != nil {
+			logrus.Error("capabilities: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	capabilitiesCmd.Flags().StringVar(&rulesFile, "rules", "", "YAML file of additional rules to include, as skrt --rules reads.")
+	capabilitiesCmd.Flags().StringArrayVar(&plugins, "plugin", nil, "Plugin to include, as skrt --plugin runs. Repeat for each plugin.")
+}
+
+// Capabilities describes what a build of skrt supports. Flag, where set,
+// is the skrt flag enabling a capability that is off by default.
+type Capabilities struct {
+	Providers []ProviderCapability `json:"providers"`
+	Detectors []DetectorCapability `json:"detectors"`
+	Rules     []RuleCapability     `json:"rules"`
+	Verifiers []VerifierCapability `json:"verifiers"`
+	Reporters []ReporterCapability `json:"reporters"`
+}
+
+// ProviderCapability is a code host repos are scanned from, and the ways of
+// reaching it supported.
+type ProviderCapability struct {
+	Name     string   `json:"name"`
+	Features []string `json:"features"`
+}
+
+// DetectorCapability is a detector and the rules it reports findings under.
+type DetectorCapability struct {
+	Name  string   `json:"name"`
+	Rules []string `json:"rules,omitempty"`
+	Flag  string   `json:"flag,omitempty"`
+}
+
+// RuleCapability is a rule: where it comes from, "detector" for rules of
+// detectors, "file" for rules applying to specific files, "structured" for
+// secrets found in parsed config files, or "custom" for rules of a --rules
+// file, its default severity, and the provider verifying its findings, if
+// any.
+type RuleCapability struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+	Verifier string `json:"verifier,omitempty"`
+}
+
+// VerifierCapability is a provider secrets are verified with, and the rules
+// whose findings it verifies.
+type VerifierCapability struct {
+	Provider string   `json:"provider"`
+	Rules    []string `json:"rules"`
+	Flag     string   `json:"flag"`
+}
+
+// ReporterCapability is a way results are reported: "output" for --output
+// formats and additions to them, "store" for --store backends, and
+// "command" for subcommands reporting on scans.
+type ReporterCapability struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Flag string `json:"flag,omitempty"`
+}
+
+// Rules of detectors by detector name. Detectors of a build register
+// themselves, but not the rules they report under; plugins' rules are not
+// known until they report findings.
+var detectorRules = map[string][]string{
+	"github":            {detection.GitHubToken},
+	"gcp":               {detection.GCPServiceAccountKey},
+	"pem":               {detection.PrivateKey},
+	"jwt":               {detection.JWT},
+	"connection-string": {detection.ConnectionString},
+	"url-credential":    {detection.URLCredential},
+	"entropy":           {detection.HighEntropyBase64, detection.HighEntropyHex},
+	"keyword":           {detection.KeywordAssignment},
+}
+
+// Rules of secrets found in parsed config files.
+var structuredRules = []string{
+	structuredSecret,
+	dotenvSecret,
+	terraformSecret,
+	dockerfileSecret,
+	composeSecret,
+	devcontainerSecret,
+}
+
+// Flags enabling verification per provider, other than --verify, which
+// enables every provider's.
+var verifyFlags = map[string]string{
+	"aws":    "--verify-aws",
+	"slack":  "--verify-slack",
+	"github": "--verify-github",
+}
+
+// capabilities returns what this build supports with the current
+// configuration.
+func capabilities() Capabilities {
+	c := Capabilities{
+		Providers: []ProviderCapability{{
+			Name:     "github",
+			Features: []string{"rest-api", "graphql-api", "enterprise-server", "git-clone", "tarball-clone", "in-memory-clone", "lfs"},
+		}},
+	}
+
+	// Verifying providers by rule.
+	ruleVerifiers := map[string]string{
+		"aws-access-key-id":     "aws",
+		"aws-secret-access-key": "aws",
+	}
+	for rule, v := range verifiers {
+		ruleVerifiers[rule] = v.Provider()
+	}
+	addRules := func(source string, names ...string) {
+		for _, name := range names {
+			c.Rules = append(c.Rules, RuleCapability{Name: name, Source: source, Severity: defaultSeverity(name), Verifier: ruleVerifiers[name]})
+		}
+	}
+
+	// Detectors registered by default, then those flags register.
+	var regexRules []string
+	for _, r := range detection.Rules {
+		regexRules = append(regexRules, r.Name)
+	}
+	for _, d := range detection.Detectors() {
+		rules := detectorRules[d.Name()]
+		if d.Name() == "regex" {
+			rules = regexRules
+		}
+		c.Detectors = append(c.Detectors, DetectorCapability{Name: d.Name(), Rules: rules})
+		addRules("detector", rules...)
+	}
+	for _, d := range []struct{ name, flag string }{{"entropy", "--entropy"}, {"keyword", "--keywords"}} {
+		c.Detectors = append(c.Detectors, DetectorCapability{Name: d.name, Rules: detectorRules[d.name], Flag: d.flag})
+		addRules("detector", detectorRules[d.name]...)
+	}
+
+	addRules("structured", structuredRules...)
+	// File and custom rules may set their own severity.
+	for _, rs := range []struct {
+		source string
+		rules  []fileRule
+	}{{"file", fileRules}, {"custom", customRules}} {
+		for _, r := range rs.rules {
+			addRules(rs.source, r.Name)
+			if r.Severity != "" {
+				c.Rules[len(c.Rules)-1].Severity = r.Severity
+			}
+		}
+	}
+
+	// Verifiers by provider.
+	providerRules := map[string][]string{}
+	for rule, provider := range ruleVerifiers {
+		providerRules[provider] = append(providerRules[provider], rule)
+	}
+	for provider, rules := range providerRules {
+		sort.Strings(rules)
+		flag := verifyFlags[provider]
+		if flag == "" {
+			flag = "--verify"
+		}
+		c.Verifiers = append(c.Verifiers, VerifierCapability{Provider: provider, Rules: rules, Flag: flag})
+	}
+	sort.Slice(c.Verifiers, func(i, j int) bool { return c.Verifiers[i].Provider < c.Verifiers[j].Provider })
+
+	c.Reporters = []ReporterCapability{
+		{Name: outputRepos, Kind: "output"},
+		{Name: outputFindings, Kind: "output", Flag: "--output " + outputFindings},
+		{Name: "pattern-clusters", Kind: "output", Flag: "--pattern-clusters"},
+		{Name: "memory", Kind: "store", Flag: "--store memory"},
+	}
+	// SQL stores are available if their drivers were built in.
+	for _, d := range sql.Drivers() {
+		switch d {
+		case "sqlite3":
+			c.Reporters = append(c.Reporters, ReporterCapability{Name: "sqlite", Kind: "store", Flag: "--store sqlite:<path>"})
+		case "postgres":
+			c.Reporters = append(c.Reporters, ReporterCapability{Name: "postgres", Kind: "store", Flag: "--store postgres://..."})
+		}
+	}
+	for _, cmd := range []string{"manifest", "compare", "findings", "canary", "lsp"} {
+		c.Reporters = append(c.Reporters, ReporterCapability{Name: cmd, Kind: "command"})
+	}
+	return c
+}
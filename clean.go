@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Directories skrt was run in, whose leftover work dirs are removed.
+	cleanDirs []string
+	// Other scan artifacts to remove, such as results files and SQLite stores.
+	cleanFiles []string
+	// After cleaning, check that no artifacts or sensitive data remain.
+	cleanVerify bool
+)
+
+// workDirPrefix prefixes the temp directories repos are cloned into.
+const workDirPrefix = "tmp_"
+
+// Names ioutil.TempDir gives work dirs: workDirPrefix then a random number.
+var workDirName = regexp.MustCompile(`^` + workDirPrefix + `[0-9]+$`)
+
+// cleanCmd removes work directories left by interrupted scans. Scans normally
+// remove their own, but a killed process cannot.
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove leftover work directories and other scan artifacts",
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := false
+		for _, dir := range cleanDirs {
+			workDirs, err := findWorkDirs(dir)
+			if err != nil {
+				logrus.Error("clean: ", err)
+				os.Exit(1)
+			}
+			for _, workDir := range workDirs {
+				if err := os.RemoveAll(workDir); err != nil {
+					logrus.Error("clean: ", err)
+					failed = true
+					continue
+				}
+				logrus.Infof("Removed %s", workDir)
+			}
+		}
+		for _, file := range cleanFiles {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				logrus.Error("clean: ", err)
+				failed = true
+				continue
+			}
+			logrus.Infof("Removed %s", file)
+		}
+
+		if cleanVerify && !verifyClean(cleanDirs, cleanFiles) {
+			failed = true
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	cleanCmd.Flags().StringSliceVar(&cleanDirs, "dir", []string{"."}, "Directories scans were run in.")
+	cleanCmd.Flags().StringSliceVar(&cleanFiles, "files", nil, "Other artifacts to remove, such as results files and SQLite stores.")
+	cleanCmd.Flags().BoolVar(&cleanVerify, "verify", false, "Check that no work directories in --dir or files in --files remain.")
+}
+
+// findWorkDirs returns the work dirs in dir. Only directories named as
+// ioutil.TempDir names them are returned, so files and directories of the
+// user's that merely start with workDirPrefix are left alone.
+func findWorkDirs(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, workDirPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	var workDirs []string
+	for _, match := range matches {
+		if !workDirName.MatchString(filepath.Base(match)) {
+			continue
+		}
+		if info, err := os.Lstat(match); err == nil && info.IsDir() {
+			workDirs = append(workDirs, match)
+		}
+	}
+	return workDirs, nil
+}
+
+// verifyClean reports whether no work dirs remain in dirs and none of files
+// exist, logging each one that remains.
+func verifyClean(dirs, files []string) bool {
+	clean := true
+	for _, file := range files {
+		if _, err := os.Lstat(file); !os.IsNotExist(err) {
+			logrus.Errorf("clean: verify: %s remains", file)
+			clean = false
+		}
+	}
+	for _, dir := range dirs {
+		workDirs, err := findWorkDirs(dir)
+		if err != nil {
+			logrus.Errorf("clean: verify: %s: %v", dir, err)
+			clean = false
+			continue
+		}
+		for _, workDir := range workDirs {
+			logrus.Errorf("clean: verify: %s remains", workDir)
+			clean = false
+		}
+	}
+	return clean
+}
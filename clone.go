@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/github"
+	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	git "gopkg.in/src-d/go-git.v4"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// Clone protocols, tried in the order given by --clone-protocols.
+const (
+	protoHTTPS   = "https"
+	protoSSH     = "ssh"
+	protoTarball = "tarball"
+)
+
+// cloneRepo fetches repo's default branch into repoDir, trying each protocol
+// in cloneProtocols in order until one succeeds. Partial checkouts from failed
+// attempts are removed before the next attempt. If the repo is empty, the
+// error is returned as is, satisfying isEmptyRepoError.
+func cloneRepo(ctx context.Context, client *github.Client, owner string, repo *github.Repository, repoDir string) error {
+	var errs []string
+	for _, proto := range cloneProtocols {
+		var err error
+		if proto == protoTarball {
+			err = downloadTarball(ctx, client, owner, repo, repoDir)
+		} else {
+			var opts *git.CloneOptions
+			if opts, err = gitCloneOptions(proto, repo); err == nil {
+				_, err = git.PlainCloneContext(ctx, repoDir, false, opts)
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		os.RemoveAll(repoDir)
+		// Other protocols cannot fetch commits that do not exist.
+		if isEmptyRepoError(err) {
+			return err
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", proto, err))
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
+
+// cloneRepoInMemory clones repo's git objects and worktree into memory, so
+// nothing from the repo is written to disk, trying each git protocol in
+// cloneProtocols in order. Tarballs are not supported in memory.
+func cloneRepoInMemory(ctx context.Context, repo *github.Repository) (*git.Repository, billy.Filesystem, error) {
+	var errs []string
+	for _, proto := range cloneProtocols {
+		opts, err := gitCloneOptions(proto, repo)
+		if err == nil {
+			fs := memfs.New()
+			var r *git.Repository
+			if r, err = git.CloneContext(ctx, memory.NewStorage(), fs, opts); err == nil {
+				return r, fs, nil
+			}
+			if isEmptyRepoError(err) {
+				return nil, nil, err
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", proto, err))
+	}
+	return nil, nil, errors.New(strings.Join(errs, "; "))
+}
+
+// cloneDepth is the number of commits to fetch, or 0 for full history.
+func cloneDepth() int {
+	if quickScan {
+		return quickCloneDepth
+	}
+	return 0
+}
+
+// gitCloneOptions returns options to clone repo with the git protocol proto.
+func gitCloneOptions(proto string, repo *github.Repository) (*git.CloneOptions, error) {
+	opts := &git.CloneOptions{
+		// Stdout carries results by default, so progress must not.
+		Progress: os.Stderr,
+		Depth:    cloneDepth(),
+	}
+	switch proto {
+	case protoHTTPS:
+		if repo.GetCloneURL() == "" {
+			return nil, errors.New("no clone URL")
+		}
+		opts.URL = repo.GetCloneURL()
+		// Tokens authenticate over HTTPS as the password of any username.
+		if accessToken != "" {
+			opts.Auth = &githttp.BasicAuth{Username: "x-access-token", Password: accessToken}
+		}
+	case protoSSH:
+		if repo.GetSSHURL() == "" {
+			return nil, errors.New("no SSH URL")
+		}
+		auth, err := gitssh.NewSSHAgentAuth("git")
+		if err != nil {
+			return nil, err
+		}
+		opts.URL = repo.GetSSHURL()
+		opts.Auth = auth
+	default:
+		return nil, errors.New("unsupported protocol")
+	}
+	return opts, nil
+}
+
+// downloadTarball extracts an API tarball of repo's default branch into
+// repoDir. Tarballs contain no git metadata.
+func downloadTarball(ctx context.Context, client *github.Client, owner string, repo *github.Repository, repoDir string) error {
+	u, _, err := client.Repositories.GetArchiveLink(ctx, owner, repo.GetName(), github.Tarball, nil)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := downloadClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download tarball: %s", resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	return extractTar(tar.NewReader(gz), repoDir)
+}
+
+// extractTar writes regular files and directories from tr into dir, stripping
+// the single top-level directory GitHub tarballs wrap their contents in.
+// Entries that would escape dir are rejected.
+func extractTar(tr *tar.Reader, dir string) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := hdr.Name
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		if name == "" {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("tar entry %q escapes destination", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fewest repos a pattern cluster must span to be reported.
+const minClusterRepos = 2
+
+// Shortest secret value given a shape. Shorter values share shapes by chance.
+const minShapeLength = 8
+
+// Longest secret value given a shape. Longer matches are documents, such as
+// private keys, rather than tokens.
+const maxShapeLength = 200
+
+// Rules of this specificity or more match formats that are already known, so
+// their findings are left out of pattern clusters.
+const knownFormatSpecificity = 0.9
+
+// A prefix naming a token format, ex. acme_ or svc-tok-.
+var shapePrefix = regexp.MustCompile(`^([A-Za-z]{2,8}[_-]){1,3}`)
+
+// findingShape returns the shape of the secret pos matches in fileData, as
+// secretShape does, or "" if pos is of a known format or has no shape. URLs
+// with credentials take the shape of their host rather than a token's, so
+// they have none.
+func findingShape(pos SensitivePos, fileData []byte) string {
+	if specificity, ok := ruleSpecificity[pos.Rule]; ok && specificity >= knownFormatSpecificity {
+		return ""
+	}
+	if pos.End > len(fileData) {
+		return ""
+	}
+	match := fileData[pos.Start:pos.End]
+	if bytes.Contains(match, []byte("://")) {
+		return ""
+	}
+	if v := assignedValue(match); v != "" {
+		return secretShape(v)
+	}
+	return secretShape(strings.Trim(string(match), " \t\"'`"))
+}
+
+// secretShape returns the structure of secret, free of its content: its
+// prefix, if any, then a character class of the rest and its length, as a
+// regular expression, ex. acme_[0-9a-f]{32}. Letters are only a-f or A-F if
+// the rest is hex. Values too short or long, or
+// containing whitespace, have no shape.
+func secretShape(secret string) string {
+	if len(secret) < minShapeLength || len(secret) > maxShapeLength || strings.ContainsAny(secret, " \t\r\n") {
+		return ""
+	}
+	prefix := shapePrefix.FindString(secret)
+	rest := secret[len(prefix):]
+	if rest == "" {
+		return ""
+	}
+
+	var lower, upper, digit bool
+	hex := true
+	symbols := map[rune]bool{}
+	for _, r := range rest {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+			hex = hex && r <= 'f'
+		case unicode.IsUpper(r):
+			upper = true
+			hex = hex && r <= 'F'
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbols[r] = true
+		}
+	}
+	class := ""
+	if digit {
+		class += "0-9"
+	}
+	switch {
+	case upper && hex:
+		class += "A-F"
+	case upper:
+		class += "A-Z"
+	}
+	switch {
+	case lower && hex:
+		class += "a-f"
+	case lower:
+		class += "a-z"
+	}
+	var syms []string
+	for r := range symbols {
+		if r != '-' {
+			syms = append(syms, regexp.QuoteMeta(string(r)))
+		}
+	}
+	sort.Strings(syms)
+	class += strings.Join(syms, "")
+	// A trailing - is literal in a class.
+	if symbols['-'] {
+		class += "-"
+	}
+	return regexp.QuoteMeta(prefix) + "[" + class + "]{" + strconv.Itoa(len(rest)) + "}"
+}
+
+// PatternCluster is a secret shape, ex. acme_[0-9a-f]{32}, found in several
+// repos: likely one format of internal token, hardcoded by many teams.
+// Findings counts the findings of the shape, and Repos and Rules list the
+// repos they are in and the rules that found them.
+type PatternCluster struct {
+	Shape    string   `json:"shape"`
+	Findings int      `json:"findings"`
+	Repos    []string `json:"repos"`
+	Rules    []string `json:"rules"`
+}
+
+// patternClusters is a ResultWriter grouping findings across repos by their
+// secret's shape.
+type patternClusters map[string]*PatternCluster
+
+func (pc patternClusters) WriteRepo(sr SensitiveRepo) error {
+	return sr.eachFile(func(file SensitiveFile) error {
+		for _, pos := range file.Positions {
+			if pos.Shape == "" {
+				continue
+			}
+			c, ok := pc[pos.Shape]
+			if !ok {
+				c = &PatternCluster{Shape: pos.Shape}
+				pc[pos.Shape] = c
+			}
+			c.Findings++
+			c.Repos = appendUnique(c.Repos, sr.Name)
+			c.Rules = appendUnique(c.Rules, pos.Rule)
+		}
+		return nil
+	})
+}
+
+// clusters returns the shapes found in at least minClusterRepos repos, those
+// spanning the most repos first.
+func (pc patternClusters) clusters() []PatternCluster {
+	var cs []PatternCluster
+	for _, c := range pc {
+		if len(c.Repos) >= minClusterRepos {
+			cs = append(cs, *c)
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if len(cs[i].Repos) != len(cs[j].Repos) {
+			return len(cs[i].Repos) > len(cs[j].Repos)
+		}
+		if cs[i].Findings != cs[j].Findings {
+			return cs[i].Findings > cs[j].Findings
+		}
+		return cs[i].Shape < cs[j].Shape
+	})
+	return cs
+}
+
+// appendUnique appends s to list if list does not contain it.
+func appendUnique(list []string, s string) []string {
+	for _, e := range list {
+		if e == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// commentSyntax is how a language marks comments: Line markers comment out
+// the rest of their line, and BlockStart and BlockEnd enclose a block
+// comment.
+type commentSyntax struct {
+	Line       []string
+	BlockStart string
+	BlockEnd   string
+}
+
+var (
+	cComments    = commentSyntax{Line: []string{"//"}, BlockStart: "/*", BlockEnd: "*/"}
+	hashComments = commentSyntax{Line: []string{"#"}}
+	sqlComments  = commentSyntax{Line: []string{"--"}, BlockStart: "/*", BlockEnd: "*/"}
+	xmlComments  = commentSyntax{BlockStart: "<!--", BlockEnd: "-->"}
+)
+
+// commentSyntaxes maps file extensions to their comment syntax.
+var commentSyntaxes = map[string]commentSyntax{
+	".go":     cComments,
+	".c":      cComments,
+	".h":      cComments,
+	".cc":     cComments,
+	".cpp":    cComments,
+	".hpp":    cComments,
+	".cs":     cComments,
+	".java":   cComments,
+	".kt":     cComments,
+	".kts":    cComments,
+	".scala":  cComments,
+	".groovy": cComments,
+	".gradle": cComments,
+	".swift":  cComments,
+	".m":      cComments,
+	".rs":     cComments,
+	".js":     cComments,
+	".jsx":    cComments,
+	".ts":     cComments,
+	".tsx":    cComments,
+	".dart":   cComments,
+	".proto":  cComments,
+
+	".php": {Line: []string{"//", "#"}, BlockStart: "/*", BlockEnd: "*/"},
+	".tf":  {Line: []string{"#", "//"}, BlockStart: "/*", BlockEnd: "*/"},
+	".hcl": {Line: []string{"#", "//"}, BlockStart: "/*", BlockEnd: "*/"},
+
+	".py":   hashComments,
+	".rb":   hashComments,
+	".pl":   hashComments,
+	".r":    hashComments,
+	".sh":   hashComments,
+	".bash": hashComments,
+	".zsh":  hashComments,
+	".ps1":  hashComments,
+	".yaml": hashComments,
+	".yml":  hashComments,
+	".toml": hashComments,
+	".env":  hashComments,
+	".conf": hashComments,
+	".cfg":  hashComments,
+
+	".properties": {Line: []string{"#", "!"}},
+	".ini":        {Line: []string{";", "#"}},
+	".sql":        sqlComments,
+	".lua":        {Line: []string{"--"}},
+
+	".html":   xmlComments,
+	".xml":    xmlComments,
+	".plist":  xmlComments,
+	".config": xmlComments,
+	".csproj": xmlComments,
+}
+
+// commentSyntaxFor returns the comment syntax of the file at the
+// slash-separated relPath, if known.
+func commentSyntaxFor(relPath string) (commentSyntax, bool) {
+	base := path.Base(relPath)
+	switch {
+	case base == "Dockerfile", base == "Makefile", base == "Vagrantfile",
+		strings.HasPrefix(base, ".env"), strings.HasPrefix(base, "Dockerfile."):
+		return hashComments, true
+	}
+	syntax, ok := commentSyntaxes[strings.ToLower(path.Ext(base))]
+	return syntax, ok
+}
+
+// uncomment returns a copy of data with comment markers replaced by spaces,
+// so commented-out code reads as code at the same offsets, and the byte
+// ranges of the comments. A marker only starts a comment at the start of a
+// line or after whitespace, so markers inside most strings and URLs, such as
+// "http://", are left alone.
+func uncomment(data []byte, syntax commentSyntax) (masked []byte, ranges [][2]int) {
+	masked = append([]byte(nil), data...)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			masked[i] = ' '
+		}
+	}
+	startsComment := func(i int, marker string) bool {
+		return marker != "" && bytes.HasPrefix(data[i:], []byte(marker)) &&
+			(i == 0 || data[i-1] == ' ' || data[i-1] == '\t' || data[i-1] == '\n')
+	}
+
+	for i := 0; i < len(data); {
+		if startsComment(i, syntax.BlockStart) {
+			end := bytes.Index(data[i+len(syntax.BlockStart):], []byte(syntax.BlockEnd))
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += i + len(syntax.BlockStart)
+				blank(end, end+len(syntax.BlockEnd))
+				end += len(syntax.BlockEnd)
+			}
+			blank(i, i+len(syntax.BlockStart))
+			// Blank the leading '*' of each line in the block, as in
+			// " * password = ...".
+			for j := i; j < end; j++ {
+				if data[j] != '\n' {
+					continue
+				}
+				k := j + 1
+				for k < end && (data[k] == ' ' || data[k] == '\t') {
+					k++
+				}
+				if k < end && data[k] == '*' && !startsComment(k, syntax.BlockEnd) {
+					masked[k] = ' '
+				}
+			}
+			ranges = append(ranges, [2]int{i, end})
+			i = end
+			continue
+		}
+		matched := false
+		for _, marker := range syntax.Line {
+			if !startsComment(i, marker) {
+				continue
+			}
+			end := nextLineStart(data, i)
+			// Blank runs of the marker, as in "##" or "////".
+			j := i
+			for bytes.HasPrefix(data[j:], []byte(marker)) {
+				j += len(marker)
+			}
+			blank(i, j)
+			ranges = append(ranges, [2]int{i, end})
+			i, matched = end, true
+			break
+		}
+		if !matched {
+			i++
+		}
+	}
+	return masked, ranges
+}
+
+// markCommented sets Commented on each of positions starting in a comment in
+// fileData, and returns the positions of rule matches only found once the
+// file's comment markers are removed, also marked Commented. Rules anchored
+// to the start of a line can then match commented-out code.
+func markCommented(relPath string, fileData []byte, positions []SensitivePos) (uncovered []SensitivePos) {
+	syntax, ok := commentSyntaxFor(relPath)
+	if !ok {
+		return nil
+	}
+	masked, ranges := uncomment(fileData, syntax)
+	if len(ranges) == 0 {
+		return nil
+	}
+	inComment := func(pos SensitivePos) bool {
+		for _, r := range ranges {
+			if pos.Start >= r[0] && pos.Start < r[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range positions {
+		if inComment(positions[i]) {
+			positions[i].Commented = true
+		}
+	}
+	for _, pos := range matchRules(relPath, masked) {
+		if !inComment(pos) || overlapsRule(positions, pos) {
+			continue
+		}
+		pos.Commented = true
+		uncovered = append(uncovered, pos)
+	}
+	return uncovered
+}
+
+// overlapsRule reports whether any of positions is from pos's rule and
+// overlaps pos.
+func overlapsRule(positions []SensitivePos, pos SensitivePos) bool {
+	for _, p := range positions {
+		if p.Rule == pos.Rule && p.Start < pos.End && pos.Start < p.End {
+			return true
+		}
+	}
+	return false
+}
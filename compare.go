@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Orgs compared, and optionally the stores their past scans were saved
+	// to, in the same order.
+	compareOrgs   []string
+	compareStores []string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare orgs' exposure, normalized by their size",
+	Long: `Compare orgs' exposure, normalized by their size.
+
+Each --org is scanned and reported with its findings per 1,000 files checked
+and the share of its findings of each severity, so orgs of different sizes,
+ex. after an acquisition or across business units, can be benchmarked
+against each other. If a --store is given for each org, in the same order,
+each org's remediation speed is reported from the scans saved to it: how
+many findings were fixed, in a median of how many days, and how long the
+rest have been open. The report holds no matched data.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(compareOrgs) < 2 {
+			logrus.Error("compare: at least two --org are required")
+			os.Exit(1)
+		}
+		if len(compareStores) != 0 && len(compareStores) != len(compareOrgs) {
+			logrus.Error("compare: give one --store per --org, in the same order, or none")
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		client := newClient(ctx)
+		for _, org := range compareOrgs {
+			if err := preflight(ctx, client, org); err != nil {
+				logrus.Error("compare: ", err)
+				os.Exit(1)
+			}
+		}
+
+		c := Comparison{GeneratedAt: time.Now().UTC()}
+		for i, org := range compareOrgs {
+			oc := newOrgComparison(org)
+			if err := CrawlOrg(ctx, client, org, oc); err != nil {
+				logrus.Error("compare: ", err)
+				os.Exit(1)
+			}
+			if len(compareStores) != 0 {
+				if err := oc.addRemediation(compareStores[i], c.GeneratedAt); err != nil {
+					logrus.Errorf("compare: %s: %v", org, err)
+					os.Exit(1)
+				}
+			}
+			c.Orgs = append(c.Orgs, oc.summary())
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(c); err != nil {
+			logrus.Error("compare: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	compareCmd.Flags().StringSliceVar(&compareOrgs, "org", nil, "Org to compare. Repeat for each org.")
+	compareCmd.Flags().StringSliceVar(&compareStores, "store", nil, "Store the scans of the --org in the same position were saved to: sqlite:<path> or a postgres:// URL. Repeat for each org.")
+}
+
+// Comparison compares orgs' exposure by measures independent of their size.
+type Comparison struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Orgs        []OrgComparison `json:"orgs"`
+}
+
+// OrgComparison is one org's exposure: the repos and files checked, its
+// findings, their number per 1,000 files, and the count and share of them
+// of each severity. ScanErrors is the number of parts of repos that could
+// not be checked, which make an org look cleaner than it is. Remediation is
+// set if the org's store was given.
+type OrgComparison struct {
+	Org                string             `json:"org"`
+	Repos              int                `json:"repos"`
+	Files              int                `json:"files"`
+	Findings           int                `json:"findings"`
+	FindingsPer1kFiles float64            `json:"findings_per_1k_files"`
+	Severities         map[string]int     `json:"severities"`
+	SeverityMix        map[string]float64 `json:"severity_mix"`
+	ScanErrors         int                `json:"scan_errors,omitempty"`
+	Remediation        *Remediation       `json:"remediation,omitempty"`
+}
+
+// Remediation is how quickly an org fixes findings, from the scans saved to
+// its store. A finding is remediated if it was resolved, or if the org's
+// latest scan no longer found it; it took the days between when it was
+// first and last seen. Open findings have been open for the days since they
+// were first seen. Ignored findings are not counted. Stores do not record
+// findings' owners, so every finding of a store is counted as its org's.
+type Remediation struct {
+	Scans                 int     `json:"scans"`
+	Remediated            int     `json:"remediated"`
+	MedianDaysToRemediate float64 `json:"median_days_to_remediate"`
+	Open                  int     `json:"open"`
+	MedianDaysOpen        float64 `json:"median_days_open"`
+}
+
+// orgComparison is a ResultWriter tallying an org's scan into an
+// OrgComparison.
+type orgComparison struct {
+	OrgComparison
+}
+
+func newOrgComparison(org string) *orgComparison {
+	return &orgComparison{OrgComparison{Org: org, Severities: map[string]int{}}}
+}
+
+func (oc *orgComparison) WriteRepo(sr SensitiveRepo) error {
+	oc.ScanErrors += len(sr.Errors)
+	if err := oc.WriteCleanRepo(sr); err != nil {
+		return err
+	}
+	return sr.eachFile(func(file SensitiveFile) error {
+		for _, pos := range file.Positions {
+			oc.Findings++
+			oc.Severities[pos.Severity]++
+		}
+		return nil
+	})
+}
+
+func (oc *orgComparison) WriteCleanRepo(sr SensitiveRepo) error {
+	oc.Repos++
+	oc.Files += sr.checked
+	return nil
+}
+
+// summary returns oc's tally with its normalized measures computed.
+func (oc *orgComparison) summary() OrgComparison {
+	s := oc.OrgComparison
+	if s.Files > 0 {
+		s.FindingsPer1kFiles = round2(float64(s.Findings) * 1000 / float64(s.Files))
+	}
+	s.SeverityMix = make(map[string]float64, len(s.Severities))
+	for sev, n := range s.Severities {
+		s.SeverityMix[sev] = round2(float64(n) / float64(s.Findings))
+	}
+	return s
+}
+
+// addRemediation sets oc's Remediation, as of now, from the findings and
+// scans of oc's org saved to the store described by spec.
+func (oc *orgComparison) addRemediation(spec string, now time.Time) error {
+	store, err := openStore(spec)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	scans, err := store.QueryScans(oc.Org)
+	if err != nil {
+		return err
+	}
+	if len(scans) == 0 {
+		return fmt.Errorf("store has no scans of %s", oc.Org)
+	}
+	latest := scans[len(scans)-1]
+	findings, err := store.QueryFindings(FindingQuery{})
+	if err != nil {
+		return err
+	}
+
+	var fixDays, openDays []float64
+	for _, f := range findings {
+		switch {
+		case f.Status == statusIgnored:
+		case f.Status == statusResolved || f.LastSeen.Before(latest.StartedAt):
+			fixDays = append(fixDays, f.LastSeen.Sub(f.FirstSeen).Hours()/24)
+		default:
+			openDays = append(openDays, now.Sub(f.FirstSeen).Hours()/24)
+		}
+	}
+	oc.Remediation = &Remediation{
+		Scans:                 len(scans),
+		Remediated:            len(fixDays),
+		MedianDaysToRemediate: round2(median(fixDays)),
+		Open:                  len(openDays),
+		MedianDaysOpen:        round2(median(openDays)),
+	}
+	return nil
+}
+
+// median returns the median of xs, or 0 if xs is empty. xs is sorted.
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sort.Float64s(xs)
+	mid := len(xs) / 2
+	if len(xs)%2 == 0 {
+		return (xs[mid-1] + xs[mid]) / 2
+	}
+	return xs[mid]
+}
+
+// round2 rounds x to two decimal places.
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// captureContext sets each position's Context to the surrounding text in
+// fileData: contextLines whole lines before and after the matched lines if
+// contextLines is positive, otherwise contextBytes bytes on either side of
+// the match. If both are zero no context is captured, so output never
+// contains matched data. Connection string passwords are redacted.
+func captureContext(positions []SensitivePos, fileData []byte) {
+	if contextLines <= 0 && contextBytes <= 0 {
+		return
+	}
+	for i, pos := range positions {
+		var start, end int
+		if contextLines > 0 {
+			start, end = lineBounds(fileData, pos.Start, pos.End, contextLines)
+		} else {
+			start, end = pos.Start-contextBytes, pos.End+contextBytes
+			if start < 0 {
+				start = 0
+			}
+			if end > len(fileData) {
+				end = len(fileData)
+			}
+		}
+		positions[i].Context = detection.RedactPasswords(string(fileData[start:end]))
+	}
+}
+
+// lineBounds returns the bounds of the lines containing data[start:end],
+// extended by n lines on either side.
+func lineBounds(data []byte, start, end, n int) (int, int) {
+	start = bytes.LastIndexByte(data[:start], '\n') + 1
+	for i := 0; i < n && start > 0; i++ {
+		start = bytes.LastIndexByte(data[:start-1], '\n') + 1
+	}
+
+	if end == 0 || data[end-1] != '\n' {
+		end = nextLineStart(data, end)
+	}
+	for i := 0; i < n && end < len(data); i++ {
+		end = nextLineStart(data, end)
+	}
+	return start, end
+}
+
+// nextLineStart returns the offset after the next newline at or after off,
+// or len(data) if there is none.
+func nextLineStart(data []byte, off int) int {
+	if i := bytes.IndexByte(data[off:], '\n'); i >= 0 {
+		return off + i + 1
+	}
+	return len(data)
+}
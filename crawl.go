@@ -2,173 +2,738 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/estroz/seekret/detection"
+	"github.com/estroz/seekret/scanerr"
 	"github.com/google/go-github/github"
 	"github.com/sirupsen/logrus"
+	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
 	git "gopkg.in/src-d/go-git.v4"
 )
 
 // TODO: configuration for full org scan, repo scan, or specific files.
 // The latter case is useful for scanning PR's and gists.
-// TODO: ignore git hashes. Solution: check git tree for commits with corresponding random string
 
 // SensitivePos is the byte frame containing sensitive data. Start and End are
-// starting and ending bytes of data.
+// starting and ending bytes of data, and KeyPath the key path of the value
+// containing it in a JSON or YAML file, ex. spec.containers[0].env[2].value,
+// or the variable it is assigned to in a dotenv file.
+// Rule names the rule that matched, and Remediation optionally describes how
+// to fix this kind of leak. Severity, Tags, and Owner are set from the rule's
+// --rule-map entry, if any; Severity otherwise defaults by rule. Confidence
+// scores, from 0 to 1, how likely the data is a real secret.
+// Obfuscation names the obfuscation undone to find the data, if any. Context
+// is the surrounding text, if context capture is enabled. Verified is set if
+// the data was confirmed to be a live credential. Commented is set if the
+// data is in a comment, as in commented-out code. Details holds facts about
+// the secret decoded by the rule, such as the account it belongs to. Shape is
+// the structure of the secret, free of its content, if pattern clusters are
+// reported.
+// Verification is the state of checking the data with its provider, if it
+// was checked.
+// IntroducedCommit and IntroducedAt identify the commit that introduced the
+// data, and ExposureDays how long ago that was, if secret age is computed.
+// Public is set if data found in a private repo is also in a public one, at
+// PublicURL.
 type SensitivePos struct {
-	Start, End int
+	Start       int               `json:"start"`
+	End         int               `json:"end"`
+	KeyPath     string            `json:"key_path,omitempty"`
+	Rule        string            `json:"rule,omitempty"`
+	Remediation string            `json:"remediation,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Confidence  float64           `json:"confidence,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Obfuscation string            `json:"obfuscation,omitempty"`
+	Context     string            `json:"context,omitempty"`
+	Verified    bool              `json:"verified,omitempty"`
+	Commented   bool              `json:"commented,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Shape       string            `json:"shape,omitempty"`
+
+	Verification string `json:"verification,omitempty"`
+
+	IntroducedCommit string     `json:"introduced_commit,omitempty"`
+	IntroducedAt     *time.Time `json:"introduced_at,omitempty"`
+	ExposureDays     int        `json:"exposure_days,omitempty"`
+
+	Public    bool   `json:"public,omitempty"`
+	PublicURL string `json:"public_url,omitempty"`
 }
 
-// SensitiveFile is a file with one or more sensitive data.
+// SensitiveFile is a file with one or more sensitive data. Commit is set if
+// the data was found in a past version of the file rather than the checkout.
 type SensitiveFile struct {
-	Path      string
-	Positions []SensitivePos
+	Path      string         `json:"path"`
+	Commit    string         `json:"commit,omitempty"`
+	Positions []SensitivePos `json:"positions"`
 }
 
-// SensitiveRepo is a repo with one or more sensitive files.
+// SensitiveRepo is a repo with one or more sensitive files. Errors lists
+// every part of the repo that could not be checked. Kinds lists what sets the
+// repo apart from ordinary repos, ex. that it is empty or a template, and
+// LFSPointers counts the Git LFS pointer files left unchecked. QuarantinePR
+// is the URL of the pull request removing the repo's verified secrets, if
+// one was opened. Dotfiles lists the repos, as owner/name, the repo's dev
+// container configs install dotfiles from.
 type SensitiveRepo struct {
-	Name  string
-	Files []SensitiveFile
+	Name         string          `json:"name"`
+	Kinds        []string        `json:"kinds,omitempty"`
+	Files        []SensitiveFile `json:"files"`
+	Errors       []ScanError     `json:"errors,omitempty"`
+	LFSPointers  int             `json:"lfs_pointers,omitempty"`
+	QuarantinePR string          `json:"quarantine_pr,omitempty"`
+	Dotfiles     []string        `json:"dotfiles,omitempty"`
+
+	// Number of files checked.
+	checked int
+	// Files past spillThreshold findings, on disk, the number of findings
+	// in Files, and whether spilling failed. Read files with eachFile.
+	spill    *findingSpill
+	inMemory int
+	noSpill  bool
+}
+
+// ScanError describes a failure that left part of a repo unchecked. Op is the
+// failed operation, ex. "clone" or "read", and Path the file involved, if any.
+// Kind is "clone", "api", or "detect", as Err, the failure as a
+// *scanerr.CloneError, *scanerr.APIError, or *scanerr.DetectError, is.
+// Retriable is set if the failure is likely transient.
+type ScanError struct {
+	Op        string `json:"op"`
+	Path      string `json:"path,omitempty"`
+	Kind      string `json:"kind"`
+	Retriable bool   `json:"retriable,omitempty"`
+	Error     string `json:"error"`
+	Err       error  `json:"-"`
+}
+
+// addError records err in sr as a typed error. Errors are reported with sr
+// and summarized once the scan completes rather than logged.
+func (sr *SensitiveRepo) addError(op, path string, err error) {
+	typed := newScanError(sr.Name, op, path, err)
+	sr.Errors = append(sr.Errors, ScanError{
+		Op:        op,
+		Path:      path,
+		Kind:      scanerr.Kind(typed),
+		Retriable: scanerr.Retriable(typed),
+		Error:     err.Error(),
+		Err:       typed,
+	})
 }
 
+// Severity of findings of credentials known to be unusable, such as JWTs
+// past their expiry and secrets their provider rejected.
+const severityExpired = "low"
+
 // Default name of the .credignore file. This file is formatted as a newline
 // delimited list of files with paths relative to the repo directory. Each file
 // in this list will not be checked for sensitive data.
 const credIgnoreFile = ".credignore"
 
-// CrawlOrg pulls all public GitHub repos owned by an org, then iteratively
-// checks each repos' files for information appearing to be sensitive. A repo
-// MAY have a '.credignore' file listing files with non-sensitive credentials
-// that can be ignored.
-func CrawlOrg(ctx context.Context, client *github.Client, orgName string) (srs []SensitiveRepo) {
+// Ignore what repos say about their own findings: .credignore,
+// .seekret.yaml, and the dependency files of their ecosystems skipped by
+// default. Set when repos may be controlled by whoever would hide findings
+// in them.
+var ignoreRepoSuppressions bool
+
+// CrawlOrg pulls all public GitHub repos owned by an org, and private ones
+// too if checkExposure is set, then iteratively checks each repos' files for
+// information appearing to be sensitive. A repo MAY have a '.credignore'
+// file listing files with non-sensitive credentials that can be ignored. Each
+// repo with sensitive data or scan errors is written to rw as soon as it has
+// been checked. An error is returned only if the crawl could not start, as an
+// *scanerr.APIError if the org's repos could not be listed.
+func CrawlOrg(ctx context.Context, client *github.Client, orgName string, rw ResultWriter) error {
 
-	// Request all repos in org using GitHub API.
-	opt := &github.RepositoryListByOrgOptions{Type: "public"}
-	repos, _, err := client.Repositories.ListByOrg(ctx, orgName, opt)
+	// Request all repos in org using GitHub API. Exposure checks only
+	// apply to private repos, so those are listed too when enabled.
+	repoType := "public"
+	if checkExposure {
+		repoType = "all"
+	}
+	repos, err := listOrgRepos(ctx, client, orgName, repoType)
 	if err != nil {
-		logrus.Error("CrawlOrg: ListByOrg: ", err)
-		return nil
+		return &scanerr.APIError{Op: "list repos", Err: err, Retriable: scanerr.Transient(err)}
 	}
 
+	// Scan the riskiest repos first so long runs produce findings early.
+	prioritizeRepos(repos, time.Now())
+
+	return CrawlRepos(ctx, client, orgName, repos, rw)
+}
+
+// CrawlRepos checks each of repos, all owned by owner, for sensitive data in
+// order, writing each repo with sensitive data or scan errors to rw as soon
+// as it has been checked, and other repos too if rw is a cleanRepoWriter.
+// An error is returned only if the crawl could not start.
+func CrawlRepos(ctx context.Context, client *github.Client, owner string, repos []*github.Repository, rw ResultWriter) error {
+
 	// Temp dir for repos
 	cwd, err := os.Getwd()
 	if err != nil {
-		logrus.Error("CrawlOrg: Getwd: ", err)
-		return nil
+		return err
 	}
-	tmpDir, err := ioutil.TempDir(cwd, "tmp_")
+	tmpDir, err := ioutil.TempDir(cwd, workDirPrefix)
 	if err != nil {
-		logrus.Error("CrawlOrg: TempDir: ", err)
-		return nil
+		return err
 	}
 	defer os.RemoveAll(tmpDir)
 	// We are only concerned with paths relative to the tmp directory.
 	tmpDir = filepath.Base(tmpDir)
+	spillDir = tmpDir
+	defer func() { spillDir = "" }()
 
-	// Check for sensitive-looking data in each repo in repos.
+	// Without a token, pace repos to stay under anonymous limits.
+	if accessToken == "" {
+		logPoliteEstimate(len(repos))
+	}
+
+	// Scan each fork family's canonical repo fully, then only the files
+	// other members changed.
+	var families map[string]string
+	if dedupeForks && len(repos) > 1 {
+		repos, families = forkFamilies(ctx, client, owner, repos)
+	}
+	var (
+		cache  *scanCache
+		family string
+	)
+	// Full names of repos queued, so dotfiles repos are each scanned once.
+	scanned := make(map[string]bool)
 	for _, repo := range repos {
+		scanned[strings.ToLower(repo.GetFullName())] = true
+	}
+
+	// Check for sensitive-looking data in each repo in repos, which grows as
+	// dotfiles repos are followed.
+	for i := 0; i < len(repos); i++ {
+		repo := repos[i]
 		// Validate relevant API response fields
 		if repo.Name == nil || *repo.Name == "" {
 			continue
 		}
-		repoName := *repo.Name
-		if repo.CloneURL == nil || *repo.CloneURL == "" {
-			continue
+		if accessToken == "" && i > 0 {
+			time.Sleep(politeRepoDelay)
+		}
+		if root, ok := families[repo.GetName()]; ok && root != family {
+			cache, family = newScanCache(), root
 		}
 
-		// Clone the repo into our temp directory.
-		repoDir := filepath.Join(tmpDir, repoName)
-		_, err := git.PlainCloneContext(ctx, repoDir, false, &git.CloneOptions{
-			URL:      *repo.CloneURL,
-			Progress: os.Stdout,
-		})
-		if err != nil {
-			logrus.Error("CrawlOrg: PlainCloneContext: ", err)
-			continue
+		// Repos outside owner are only scanned as dotfiles, and are named
+		// in full to tell them apart.
+		repoOwner := owner
+		if login := repo.GetOwner().GetLogin(); login != "" && !strings.EqualFold(login, owner) {
+			repoOwner = login
+		}
+		sensitiveRepo := crawlRepoIsolated(ctx, client, tmpDir, repoOwner, repo, cache)
+		if repoOwner != owner {
+			sensitiveRepo.Name = repo.GetFullName()
 		}
-		// Remove the .git directory, as we are not concerned with its files.
-		gitDir := filepath.Join(tmpDir, repoName, ".git")
-		if err = os.RemoveAll(gitDir); err != nil {
-			logrus.Error("CrawlOrg: RemoveAll .git: ", err)
+		if followDotfiles {
+			repos = append(repos, dotfilesRepos(ctx, client, &sensitiveRepo, scanned)...)
 		}
 
-		// Search for a top-level .credignore file. Parse contents if found.
-		filesToIgnore := make(map[string]struct{})
-		ignoreFile := filepath.Join(tmpDir, repoName, credIgnoreFile)
-		if _, err := os.Stat(ignoreFile); err == nil {
-			// Add our .credignore file so we don't check it
-			filesToIgnore[filepath.Join(repoName, credIgnoreFile)] = struct{}{}
-
-			if ignoreData, err := ioutil.ReadFile(ignoreFile); err == nil {
-				logrus.Infof("Found %s file in repo '%s'.", credIgnoreFile, repoName)
-				// .credignore files will list relevant files line-by-line, no
-				// prefixes.
-				ignoreList := strings.Split(string(ignoreData), "\n")
-				for _, f := range ignoreList {
-					// Ignore newlines and comments, which start with '#'
-					if f != "" && f[0] != '#' {
-						filesToIgnore[f] = struct{}{}
-					}
+		// If we found any sensitive data in this repo, could not check all
+		// of it, it is of a kind reported distinctly, or it installs
+		// dotfiles, write it out now.
+		if sensitiveRepo.Files != nil || sensitiveRepo.Errors != nil || sensitiveRepo.Kinds != nil || sensitiveRepo.Dotfiles != nil {
+			if err := rw.WriteRepo(sensitiveRepo); err != nil {
+				logrus.Error("CrawlRepos: WriteRepo: ", err)
+			}
+		} else if cw, ok := rw.(cleanRepoWriter); ok {
+			if err := cw.WriteCleanRepo(sensitiveRepo); err != nil {
+				logrus.Error("CrawlRepos: WriteCleanRepo: ", err)
+			}
+		}
+		sensitiveRepo.closeSpill()
+	}
+
+	return nil
+}
+
+// crawlRepoIsolated runs crawlRepo in a new, uniquely named work directory
+// under tmpDir, accessible only by the current user. The directory is removed
+// when the repo has been checked, even if checking panics, so no checkout
+// outlives its scan.
+func crawlRepoIsolated(ctx context.Context, client *github.Client, tmpDir, owner string, repo *github.Repository, cache *scanCache) (sensitiveRepo SensitiveRepo) {
+	// TempDir creates directories with mode 0700.
+	workDir, err := ioutil.TempDir(tmpDir, "repo_")
+	if err != nil {
+		sensitiveRepo.Name = repo.GetName()
+		sensitiveRepo.addError("workdir", "", err)
+		return sensitiveRepo
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			logrus.Error("crawlRepoIsolated: RemoveAll: ", err)
+		}
+		if r := recover(); r != nil {
+			sensitiveRepo.Name = repo.GetName()
+			sensitiveRepo.addError("panic", "", fmt.Errorf("%v", r))
+		}
+	}()
+
+	return crawlRepo(ctx, client, workDir, owner, repo, cache)
+}
+
+// crawlRepo clones repo, owned by owner, into tmpDir and checks its files for
+// sensitive data, reusing results in cache for files identical to ones
+// already checked in repo's fork family. Failures are recorded in the
+// returned SensitiveRepo's Errors rather than aborting, so callers can tell a
+// clean repo from one with blind spots.
+func crawlRepo(ctx context.Context, client *github.Client, tmpDir, owner string, repo *github.Repository, cache *scanCache) SensitiveRepo {
+	repoName := repo.GetName()
+	sensitiveRepo := SensitiveRepo{
+		Name: repoName,
+	}
+
+	if quickScan {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, quickRepoTimeout)
+		defer cancel()
+	}
+
+	// Clone the repo into memory if requested, otherwise into our temp
+	// directory. r is nil if the repo was fetched without history.
+	var (
+		fs  billy.Filesystem
+		r   *git.Repository
+		err error
+	)
+	repoDir := filepath.Join(tmpDir, repoName)
+	if inMemory {
+		r, fs, err = cloneRepoInMemory(ctx, repo)
+	} else {
+		err = cloneRepo(ctx, client, owner, repo, repoDir)
+		fs = osfs.New(repoDir)
+		r, _ = git.PlainOpen(repoDir)
+	}
+	if isEmptyRepoError(err) {
+		sensitiveRepo.addKind(repoEmpty)
+		return sensitiveRepo
+	}
+	if err != nil {
+		sensitiveRepo.addError("clone", "", err)
+		return sensitiveRepo
+	}
+	if templateRepos[repo.GetHTMLURL()] {
+		sensitiveRepo.addKind(repoTemplate)
+	}
+
+	// Check LFS objects in place of their pointers, as in a checkout made
+	// with git lfs installed.
+	if fetchLFS {
+		fetchLFSObjects(ctx, &sensitiveRepo, repo, fs)
+	}
+
+	// In quick mode, only check files changed recently. Tarballs have no
+	// history, so all of their files are checked.
+	var onlyFiles map[string]bool
+	if quickScan && r != nil {
+		onlyFiles, err = recentlyChangedFiles(r, time.Now().Add(-quickSince))
+		if err != nil {
+			sensitiveRepo.addError("log", "", err)
+		}
+	}
+
+	// Only check what forks changed from their upstream, including in
+	// commits since superseded.
+	if forkDivergenceOnly && repo.GetFork() {
+		d, ok, err := forkDivergence(ctx, client, owner, repo)
+		switch {
+		case err != nil:
+			sensitiveRepo.addError("compare", "", err)
+		case !ok:
+			logrus.Infof("%s diverges too far from its upstream to compare; checking all of it.", repoName)
+		default:
+			if onlyFiles == nil {
+				onlyFiles = d.Files
+			} else {
+				for f := range onlyFiles {
+					onlyFiles[f] = onlyFiles[f] && d.Files[f]
+				}
+			}
+			if r != nil {
+				files, err := scanDivergentCommits(r, d.Commits)
+				if err != nil {
+					sensitiveRepo.addError("history", "", err)
+				}
+				for _, file := range files {
+					sensitiveRepo.addFile(file)
 				}
 			}
 		}
+	}
+
+	// Watchlisted secrets are searched for in history too, since any past
+	// occurrence of a known-leaked secret matters during an incident.
+	if watchlist != nil && r != nil {
+		files, err := findWatchlistedHistory(r)
+		if err != nil {
+			sensitiveRepo.addError("history", "", err)
+		}
+		for _, file := range files {
+			sensitiveRepo.addFile(file)
+		}
+	}
+
+	scanFS(ctx, &sensitiveRepo, fs, r, repoDir, onlyFiles, cache)
+	sensitiveRepo.Dotfiles = devcontainerDotfiles(fs)
+
+	if secretAge && r != nil {
+		if err := annotateSecretAge(r, fs, sensitiveRepo.Files, time.Now()); err != nil {
+			sensitiveRepo.addError("age", "", err)
+		}
+	}
+	if checkExposure && repo.GetPrivate() {
+		if err := checkPublicExposure(ctx, client, repo, fs, sensitiveRepo.Files); err != nil {
+			sensitiveRepo.addError("exposure", "", err)
+		}
+	}
+	if quarantine {
+		pr, err := quarantineSecrets(ctx, client, repo, fs, sensitiveRepo.Files)
+		if pr != nil {
+			sensitiveRepo.QuarantinePR = pr.GetHTMLURL()
+			logrus.Infof("%s: opened quarantine pull request %s", sensitiveRepo.Name, pr.GetHTMLURL())
+		}
+		if err != nil {
+			sensitiveRepo.addError("quarantine", "", err)
+		}
+	}
+
+	// Remove the .git directory now that history is no longer needed.
+	// In-memory clones keep git objects out of the worktree.
+	if !inMemory {
+		gitDir := filepath.Join(repoDir, ".git")
+		if err := os.RemoveAll(gitDir); err != nil {
+			logrus.Error("crawlRepo: RemoveAll .git: ", err)
+		}
+	}
+
+	return sensitiveRepo
+}
+
+// scanFS checks each file in fs, a repo's worktree rooted on disk at repoDir
+// unless scanning in memory, for sensitive data, honoring a top-level
+// .credignore and, within repoConfigPolicy, .seekret.yaml, unless
+// ignoreRepoSuppressions is set. If onlyFiles is
+// non-nil, only the slash-separated paths it maps to true are checked.
+// Results in cache, if non-nil, are reused for identical files. Findings and
+// failures are added to sensitiveRepo. Git LFS pointer files are counted
+// rather than checked, and a repo mostly of them is marked as such. Hex
+// strings that are objects of r, the repo's clone, if non-nil, are hashes
+// rather than secrets.
+func scanFS(ctx context.Context, sensitiveRepo *SensitiveRepo, fs billy.Filesystem, r *git.Repository, repoDir string, onlyFiles map[string]bool, cache *scanCache) {
+	// Search for a top-level .credignore file. Parse contents if found.
+	filesToIgnore := make(map[string]struct{})
+	if !ignoreRepoSuppressions {
+		if ignoreData, err := readFSFile(fs, credIgnoreFile); err == nil {
+			// Add our .credignore file so we don't check it
+			filesToIgnore[credIgnoreFile] = struct{}{}
 
-		// Now check each file in the repo, other than excluded files, for
-		// sensitive content.
-		sensitiveRepo := SensitiveRepo{
-			Name: repoName,
+			logrus.Infof("Found %s file in repo '%s'.", credIgnoreFile, sensitiveRepo.Name)
+			parseCredIgnore(ignoreData, filesToIgnore)
+		} else if !os.IsNotExist(err) {
+			sensitiveRepo.addError("read", credIgnoreFile, err)
 		}
-		f := func(path string, info os.FileInfo, err error) error {
-			if info.IsDir() {
-				return nil
+	}
+
+	// Apply the repo's own scan config, if any and allowed. An invalid config
+	// is reported and the org baseline still applies.
+	var cfg *repoConfig
+	if !repoConfigPolicy.Disable && !ignoreRepoSuppressions {
+		if cfgData, err := readFSFile(fs, repoConfigFile); err == nil {
+			filesToIgnore[repoConfigFile] = struct{}{}
+			if cfg, err = parseRepoConfig(cfgData); err != nil {
+				sensitiveRepo.addError("config", repoConfigFile, err)
 			}
+		} else if !os.IsNotExist(err) {
+			sensitiveRepo.addError("read", repoConfigFile, err)
+		}
+	}
 
-			// Trim tmp directory and repo name from path.
-			relPath, err := filepath.Rel(repoDir, path)
-			if err != nil {
-				logrus.Warnf("WalkFunc: found sensitive file '%s', rel path error: ", err)
-				return nil
+	// Skip generated dependency files of the repo's ecosystems.
+	var ecos []ecosystem
+	if !ignoreRepoSuppressions {
+		ecos = detectEcosystems(fs)
+	}
+
+	// Now check each file in the repo, other than excluded files, for
+	// sensitive content.
+	var checked int
+	f := func(relPath string, info os.FileInfo, err error) {
+		if err != nil {
+			sensitiveRepo.addError("walk", relPath, err)
+			return
+		}
+		if _, ok := filesToIgnore[relPath]; ok {
+			return
+		}
+		slashPath := filepath.ToSlash(relPath)
+		// The clone's .git directory is kept until history is no longer
+		// needed, but we are not concerned with its files.
+		if strings.HasPrefix(slashPath, ".git/") {
+			return
+		}
+		if ecosystemSkips(ecos, slashPath) {
+			return
+		}
+		if quickScan && info.Size() > quickMaxFileSize {
+			return
+		}
+		if onlyFiles != nil && !onlyFiles[slashPath] {
+			return
+		}
+		// Files the repo excludes are still checked for locked rules.
+		excluded := cfg != nil && cfg.excluded(slashPath, repoConfigPolicy)
+		if excluded && len(repoConfigPolicy.LockedRules) == 0 {
+			return
+		}
+
+		checked++
+		paceIO()
+
+		// Drops findings in data that are digests, hashes, or dummy values,
+		// and in quick mode those of rules not specific enough.
+		dropNonSecrets := func(data []byte, positions []SensitivePos) []SensitivePos {
+			if quickScan {
+				positions = dropUnspecific(positions)
 			}
-			if _, ok := filesToIgnore[relPath]; ok {
-				return nil
+			positions = dropDigests(ecos, slashPath, data, positions)
+			positions = dropHashes(r, data, positions)
+			if !noDummyAllowlist {
+				positions = dropDummies(data, positions)
 			}
+			return positions
+		}
 
-			fileData, err := ioutil.ReadFile(path)
-			if err != nil {
-				logrus.Error("WalkFunc: ReadFile: ", err)
-				return nil
+		// Large files on disk are scanned in place rather than read onto the
+		// heap, one window at a time. Only detectors, file and custom rules,
+		// and the repo's rules run on each window; normalization, commented
+		// code, deobfuscation, decoding, structured config parsing,
+		// verification, and context capture need the whole file and are
+		// skipped.
+		var (
+			positions []SensitivePos
+			fileData  []byte
+		)
+		if watchlist == nil && !inMemory && largeFileThreshold > 0 && info.Size() > largeFileThreshold {
+			scanWindow := func(window []byte) []SensitivePos {
+				positions := HasSensitive(window)
+				positions = append(positions, checkFileRules(slashPath, window)...)
+				if cfg != nil {
+					positions = append(positions, cfg.scan(slashPath, window)...)
+				}
+				return dropNonSecrets(window, positions)
 			}
+			if positions, err = scanLargeFile(filepath.Join(repoDir, relPath), info.Size(), scanWindow); err != nil {
+				sensitiveRepo.addError("read", relPath, err)
+				return
+			}
+		} else {
+			if fileData, err = readFSFile(fs, relPath); err != nil {
+				sensitiveRepo.addError("read", relPath, err)
+				return
+			}
+			if isLFSPointer(fileData) {
+				sensitiveRepo.LFSPointers++
+				return
+			}
+			if watchlist != nil {
+				positions = findWatchlisted(fileData)
+			} else {
+				positions = cache.scanFileData(slashPath, fileData)
+				if cfg != nil {
+					positions = append(positions, cfg.scan(slashPath, fileData)...)
+				}
+				positions = dropNonSecrets(fileData, positions)
+				if verifyAWSKeys || verifyAll {
+					verifyAWSPositions(ctx, positions, fileData)
+				}
+				if verifySlack || verifyGitHub || verifyAll {
+					verifyPositions(ctx, positions, fileData)
+				}
+			}
+			captureContext(positions, fileData)
+		}
 
-			// Does this file potentially have sensitive data? Append all
-			// positions of sensitive data to this repos' list.
-			if positions := HasSensitive(fileData); positions != nil {
-				sensitiveRepo.Files = append(sensitiveRepo.Files, SensitiveFile{
-					Path:      relPath,
-					Positions: positions,
-				})
+		if excluded {
+			kept := positions[:0]
+			for _, pos := range positions {
+				if repoConfigPolicy.locked(pos.Rule) {
+					kept = append(kept, pos)
+				}
 			}
+			if positions = kept; len(positions) == 0 {
+				positions = nil
+			}
+		}
 
-			return nil
+		for i := range positions {
+			applyRuleMapping(&positions[i])
+			if cfg != nil {
+				cfg.apply(&positions[i], repoConfigPolicy)
+			}
+			// Live credentials outrank any configured severity, and
+			// expired or rejected ones rank below it.
+			if positions[i].Verified {
+				positions[i].Severity = severityVerified
+			} else if positions[i].Details["expired"] == "true" || positions[i].Verification == stateInvalid {
+				positions[i].Severity = severityExpired
+			}
+			scoreFinding(&positions[i], fileData)
+			if reportClusters && fileData != nil {
+				positions[i].Shape = findingShape(positions[i], fileData)
+			}
 		}
-		if err := filepath.Walk(repoDir, f); err != nil {
-			logrus.Error("CrawlOrg: Walk: ", err)
-			continue
+		positions = filterFindings(positions)
+
+		// Does this file potentially have sensitive data? Append all
+		// positions of sensitive data to this repos' list.
+		if positions != nil {
+			file := SensitiveFile{
+				Path:      relPath,
+				Positions: positions,
+			}
+			sensitiveRepo.addFile(file)
+			if fileStream != nil {
+				fileStream(sensitiveRepo.Name, file)
+			}
 		}
+	}
+	walkFS(fs, "", f)
 
-		// If we found any sensitive data in this repo, add to our final set.
-		if sensitiveRepo.Files != nil {
-			srs = append(srs, sensitiveRepo)
+	sensitiveRepo.checked += checked
+
+	// LFS-dominated repos have more pointers than other files.
+	if sensitiveRepo.LFSPointers*2 > checked {
+		sensitiveRepo.addKind(repoLFS)
+	}
+}
+
+// parseCredIgnore adds each file listed in the .credignore contents
+// ignoreData to filesToIgnore.
+func parseCredIgnore(ignoreData []byte, filesToIgnore map[string]struct{}) {
+	// .credignore files will list relevant files line-by-line, no
+	// prefixes.
+	ignoreList := strings.Split(string(ignoreData), "\n")
+	for _, f := range ignoreList {
+		// Ignore newlines and comments, which start with '#'
+		if f != "" && f[0] != '#' {
+			filesToIgnore[f] = struct{}{}
 		}
 	}
+}
+
+// scanFileData returns the positions of sensitive data in the file at the
+// repo-relative, slash-separated relPath, including data in commented-out
+// code, obfuscated data if deobfuscation is enabled, encoded data if
+// decoding is enabled, data of Kubernetes Secret manifests, sensitive values
+// of Terraform state and variables, secrets set in Dockerfiles, compose files,
+// and dev container configs, and values of secret-named keys in JSON, YAML, and dotenv files.
+func scanFileData(relPath string, fileData []byte) []SensitivePos {
+	positions := matchRules(relPath, fileData)
+	positions = append(positions, markCommented(relPath, fileData, positions)...)
+	if deobfuscate {
+		positions = append(positions, findObfuscated(relPath, fileData)...)
+	}
+	if decodeEncoded {
+		positions = append(positions, findEncoded(relPath, fileData)...)
+	}
+	values := structValues(relPath, fileData)
+	secrets := k8sSecrets(values)
+	tf := terraformValues(relPath, fileData, values)
+	dv := dockerValues(relPath, fileData, values)
+	dc := devcontainerValues(relPath, values)
+	positions = append(positions, findK8sSecrets(secrets, positions)...)
+	positions = append(positions, findTerraform(tf, positions)...)
+	positions = append(positions, findDocker(dv, positions)...)
+	positions = append(positions, findDevcontainer(dc, positions)...)
+	positions = append(positions, findStructured(relPath, values, positions)...)
+	labelK8sSecrets(secrets, positions)
+	labelTerraform(tf, positions)
+	labelDocker(dv, positions)
+	labelDevcontainer(dc, positions)
+	return positions
+}
 
-	return
+// matchRules returns the positions of all rule matches in fileData. Text is
+// normalized before matching so zero-width characters and homoglyphs cannot
+// hide secrets from rules.
+func matchRules(relPath string, fileData []byte) []SensitivePos {
+	normalized, mapping := normalizeText(fileData)
+	positions := HasSensitive(normalized)
+	positions = append(positions, checkFileRules(relPath, normalized)...)
+	return mapping.denormalize(positions)
+}
+
+// listOrgRepos requests every page of repos of type repoType owned by orgName,
+// using the GraphQL API if useGraphQL is set.
+func listOrgRepos(ctx context.Context, client *github.Client, orgName, repoType string) ([]*github.Repository, error) {
+	if useGraphQL {
+		return listOrgReposGraphQL(ctx, client, orgName, repoType)
+	}
+	return listOrgReposREST(ctx, client, orgName, repoType)
+}
+
+// restRepo is a repo as listed by the REST API, including is_template,
+// which go-github does not model.
+type restRepo struct {
+	*github.Repository
+	IsTemplate bool `json:"is_template"`
+}
+
+// listOrgReposREST requests every page of repos of type repoType owned by
+// orgName from the REST API, recording templates in templateRepos.
+func listOrgReposREST(ctx context.Context, client *github.Client, orgName, repoType string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	for page := 1; ; {
+		u := fmt.Sprintf("orgs/%s/repos?type=%s&per_page=100&page=%d", orgName, repoType, page)
+		req, err := client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		// Topics are only listed with their preview media type.
+		req.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
+		var listed []restRepo
+		resp, err := client.Do(ctx, req, &listed)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range listed {
+			if r.IsTemplate {
+				templateRepos[r.GetHTMLURL()] = true
+			}
+			repos = append(repos, r.Repository)
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		page = resp.NextPage
+	}
 }
 
 // HasSensitive searches fileData for any data resembling secret information,
-// ex. random strings, and returns their byte positions in fileData.
-func HasSensitive(fileData []byte) []SensitivePos {
-	return nil
+// ex. random strings, with every registered detector, and returns their byte
+// positions in fileData.
+func HasSensitive(fileData []byte) (positions []SensitivePos) {
+	for _, f := range detection.Scan(fileData) {
+		positions = append(positions, SensitivePos{
+			Start:       f.Start,
+			End:         f.End,
+			Rule:        f.Rule,
+			Remediation: f.Remediation,
+			Details:     f.Details,
+		})
+	}
+	return positions
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// Runs of base64 or base64url characters long enough to hold a secret.
+	// Hex runs match too.
+	encodedRunPattern = regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`)
+	hexRunPattern     = regexp.MustCompile(`^(?:[0-9A-Fa-f]{2})+$`)
+)
+
+// Longest encoded run decoded. Longer runs are usually binary blobs.
+const maxEncodedRun = 64 << 10
+
+// findEncoded base64- or hex-decodes long encoded strings in fileData, such as
+// Kubernetes Secret data values, and reports those whose decoded text
+// matches a rule the encoded form does not. As with findObfuscated, each
+// string is decoded in place within its line, and the reported position
+// covers the encoded string. Strings that do not decode to text are skipped.
+func findEncoded(relPath string, fileData []byte) (positions []SensitivePos) {
+	for _, loc := range encodedRunPattern.FindAllIndex(fileData, -1) {
+		if loc[1]-loc[0] > maxEncodedRun {
+			continue
+		}
+		decoded, encoding := decodeRun(string(fileData[loc[0]:loc[1]]))
+		if decoded == nil {
+			continue
+		}
+		positions = append(positions, matchDecoded(relPath, fileData, loc[0], loc[1], decoded, encoding)...)
+	}
+	return positions
+}
+
+// decodeRun decodes run as hex if it is hex, otherwise as padded or unpadded
+// base64 or base64url, and returns the decoded text and the encoding's name.
+// decoded is nil if run does not decode to printable text.
+func decodeRun(run string) (decoded []byte, encoding string) {
+	var err error
+	if hexRunPattern.MatchString(run) {
+		decoded, err = hex.DecodeString(run)
+		encoding = "hex"
+	} else {
+		trimmed := strings.TrimRight(run, "=")
+		decoded, err = base64.RawStdEncoding.DecodeString(trimmed)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(trimmed)
+		}
+		encoding = "base64"
+	}
+	if err != nil || !isText(decoded) {
+		return nil, ""
+	}
+	return decoded, encoding
+}
+
+// isText reports whether b is UTF-8 text of printable characters and
+// whitespace.
+func isText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
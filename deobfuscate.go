@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// Quoted string literals long enough to hold a secret. Group 1 or 2 is
+	// the literal's contents.
+	stringLiteralPattern = regexp.MustCompile(`"([^"\\\n]{8,})"|'([^'\\\n]{8,})'`)
+	// Arrays of at least 8 character codes, as in JS String.fromCharCode(...)
+	// calls or Python/JS list literals.
+	charCodeArrayPattern = regexp.MustCompile(`(?:(?:String\.)?fromCharCode\(|\[)\s*\d{2,3}(?:\s*,\s*\d{2,3}){7,}\s*[)\]]`)
+	// Python chr() concatenations of at least 8 characters.
+	chrChainPattern = regexp.MustCompile(`chr\(\d{2,3}\)(?:\s*\+\s*chr\(\d{2,3}\)){7,}`)
+	digitsPattern   = regexp.MustCompile(`\d+`)
+	// Template or interpolation syntax, as in {{user `x`}} or ${VAR}.
+	templatePattern = regexp.MustCompile(`\{\{|\$\{`)
+)
+
+// deobfuscation undoes one trivial obfuscation of a string literal's
+// contents.
+type deobfuscation struct {
+	name string
+	undo func([]byte) []byte
+}
+
+var literalDeobfuscations = []deobfuscation{
+	{"reversed", reverseBytes},
+	{"rot13", rot13},
+}
+
+// findObfuscated undoes common trivial obfuscations (reversed or ROT13 string
+// literals, character code arrays) and reports candidates whose decoded form
+// matches a rule the original does not. Each candidate is decoded in place
+// within its line, so rules relying on a nearby keyword still apply. The
+// reported position covers the obfuscated expression.
+func findObfuscated(relPath string, fileData []byte) (positions []SensitivePos) {
+	for _, loc := range stringLiteralPattern.FindAllSubmatchIndex(fileData, -1) {
+		inner := loc[2:4]
+		if inner[0] < 0 {
+			inner = loc[4:6]
+		}
+		// Templates and interpolations reference secrets rather than hide
+		// them, and reversed they look like literal values.
+		if templatePattern.Match(fileData[inner[0]:inner[1]]) {
+			continue
+		}
+		for _, d := range literalDeobfuscations {
+			decoded := d.undo(fileData[inner[0]:inner[1]])
+			positions = append(positions, matchDecoded(relPath, fileData, inner[0], inner[1], decoded, d.name)...)
+		}
+	}
+
+	for _, p := range []*regexp.Regexp{charCodeArrayPattern, chrChainPattern} {
+		for _, loc := range p.FindAllIndex(fileData, -1) {
+			decoded, ok := decodeCharCodes(fileData[loc[0]:loc[1]])
+			if !ok {
+				continue
+			}
+			quoted := append(append([]byte{'"'}, decoded...), '"')
+			positions = append(positions, matchDecoded(relPath, fileData, loc[0], loc[1], quoted, "char-codes")...)
+		}
+	}
+
+	return positions
+}
+
+// matchDecoded runs rules over the line containing fileData[start:end] with
+// that span replaced by decoded, and returns a position for start:end per
+// rule that matches the decoded span but not the original line.
+func matchDecoded(relPath string, fileData []byte, start, end int, decoded []byte, obfuscation string) (positions []SensitivePos) {
+	lineStart := bytes.LastIndexByte(fileData[:start], '\n') + 1
+	lineEnd := len(fileData)
+	if i := bytes.IndexByte(fileData[end:], '\n'); i >= 0 {
+		lineEnd = end + i
+	}
+
+	original := make(map[string]bool)
+	for _, pos := range matchRules(relPath, fileData[lineStart:lineEnd]) {
+		original[pos.Rule] = true
+	}
+
+	variant := make([]byte, 0, lineEnd-lineStart-(end-start)+len(decoded))
+	variant = append(variant, fileData[lineStart:start]...)
+	variant = append(variant, decoded...)
+	variant = append(variant, fileData[end:lineEnd]...)
+	decodedStart, decodedEnd := start-lineStart, start-lineStart+len(decoded)
+
+	for _, pos := range matchRules(relPath, variant) {
+		if original[pos.Rule] || pos.End <= decodedStart || pos.Start >= decodedEnd {
+			continue
+		}
+		original[pos.Rule] = true
+		pos.Start, pos.End = start, end
+		pos.Obfuscation = obfuscation
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+// decodeCharCodes converts the decimal character codes in expr to text. ok is
+// false if any code is not printable ASCII.
+func decodeCharCodes(expr []byte) (decoded []byte, ok bool) {
+	for _, digits := range digitsPattern.FindAll(expr, -1) {
+		n, err := strconv.Atoi(string(digits))
+		if err != nil || n < 0x20 || n > 0x7e {
+			// chr( and fromCharCode( contain no digits, so every match is a
+			// character code.
+			return nil, false
+		}
+		decoded = append(decoded, byte(n))
+	}
+	return decoded, true
+}
+
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
+
+func rot13(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		r[i] = c
+	}
+	return r
+}
@@ -0,0 +1,140 @@
+// Package detection finds secrets in text with named rules, so findings can
+// report which rule fired.
+package detection
+
+import (
+	"regexp"
+)
+
+// Rule flags matches of Pattern as secrets.
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Remediation string
+}
+
+// Finding is the byte range [Start, End) of data flagged by the rule named
+// Rule. Remediation optionally describes how to fix this kind of leak, and
+// Details optionally holds facts about the secret a detector decoded, such as
+// the account it belongs to.
+type Finding struct {
+	Rule        string
+	Start       int
+	End         int
+	Remediation string
+	Details     map[string]string
+}
+
+// Detector finds secrets in data. Name identifies the detector; each finding
+// names the rule within it that fired.
+type Detector interface {
+	Name() string
+	Scan(data []byte) []Finding
+}
+
+var detectors = []Detector{RegexDetector{}, GitHubTokenDetector{}, GCPDetector{}, PEMDetector{}, JWTDetector{}, ConnectionStringDetector{}, URLCredentialDetector{}}
+
+// Register adds d to the detectors run by Scan.
+func Register(d Detector) {
+	detectors = append(detectors, d)
+}
+
+// Detectors returns the registered detectors, in the order they run. The
+// RegexDetector, GitHubTokenDetector, GCPDetector, PEMDetector, JWTDetector,
+// ConnectionStringDetector, and URLCredentialDetector are registered by
+// default.
+func Detectors() []Detector {
+	return append([]Detector(nil), detectors...)
+}
+
+// Scan returns the findings of every registered detector in data.
+func Scan(data []byte) (findings []Finding) {
+	for _, d := range detectors {
+		findings = append(findings, d.Scan(data)...)
+	}
+	return findings
+}
+
+// Rules are the rules RegexDetector applies, in order.
+var Rules = []Rule{
+	{
+		Name:        "aws-access-key-id",
+		Pattern:     regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[0-9A-Z]{16}\b`),
+		Remediation: "Deactivate and delete the access key in IAM, and review CloudTrail for its use.",
+	},
+	{
+		Name:        "aws-secret-access-key",
+		Pattern:     regexp.MustCompile(`(?i)aws[\w.-]{0,20}?(secret|sk)[\w.-]{0,20}["']?\s*[:=]\s*["']?[A-Za-z0-9/+]{40}`),
+		Remediation: "Deactivate and delete the access key in IAM, and review CloudTrail for its use.",
+	},
+	{
+		Name: "generic-api-key",
+		// Quoted values may contain dots; bare values may not, so references
+		// like process.env.API_KEY are not flagged.
+		Pattern: regexp.MustCompile(`(?i)[\w.-]*(api[_-]?key|api[_-]?secret|access[_-]?token|auth[_-]?token|client[_-]?secret|secret[_-]?key)["']?\s*[:=]\s*` +
+			`(["'][\w\-+/=.~]{16,}["']|[\w\-+/=~]{16,}\b)`),
+		Remediation: "Revoke the key with its issuer, and load its replacement from the environment or a secrets manager.",
+	},
+	{
+		Name:        "generic-password",
+		Pattern:     regexp.MustCompile(`(?i)[\w.-]*(password|passwd|pwd)["']?\s*[:=]\s*["'][^"'\s$]{8,}["']`),
+		Remediation: "Change the password, and load its replacement from the environment or a secrets manager.",
+	},
+	{
+		Name:        "slack-token",
+		Pattern:     regexp.MustCompile(`\b(xox[abposr]|xoxe\.xox[bp])-[0-9A-Za-z-]{10,}|\bxapp-\d-[A-Z0-9]+-\d+-[a-f0-9]{32,}`),
+		Remediation: "Revoke the token in the Slack app's settings or with auth.revoke, and review the workspace's access logs.",
+	},
+	{
+		Name:        "slack-webhook-url",
+		Pattern:     regexp.MustCompile(`https://hooks\.slack\.com/(services|workflows|triggers)/T[A-Z0-9]+/[A-Z0-9]+/[A-Za-z0-9]{16,}`),
+		Remediation: "Regenerate the webhook in the Slack app's Incoming Webhooks settings, and load its replacement from a secrets manager.",
+	},
+	{
+		Name:        "stripe-secret-key",
+		Pattern:     regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`),
+		Remediation: "Roll the key in the Stripe dashboard, and review the account's request logs for its use.",
+	},
+	{
+		Name:        "bearer-token",
+		Pattern:     regexp.MustCompile(`(?i)\bbearer\s+[\w\-.~+/]{20,}=*`),
+		Remediation: "Revoke the token with its issuer, and have the client obtain tokens at run time.",
+	},
+}
+
+var rulesByName = make(map[string]*Rule)
+
+func init() {
+	for i := range Rules {
+		rulesByName[Rules[i].Name] = &Rules[i]
+	}
+}
+
+// Lookup returns the rule named name.
+func Lookup(name string) (Rule, bool) {
+	r, ok := rulesByName[name]
+	if !ok {
+		return Rule{}, false
+	}
+	return *r, true
+}
+
+// RegexDetector is a Detector applying Rules.
+type RegexDetector struct{}
+
+func (RegexDetector) Name() string { return "regex" }
+
+// Scan returns every match of every rule in data, grouped by rule.
+func (RegexDetector) Scan(data []byte) (findings []Finding) {
+	for _, rule := range Rules {
+		for _, loc := range rule.Pattern.FindAllIndex(data, -1) {
+			findings = append(findings, Finding{
+				Rule:        rule.Name,
+				Start:       loc[0],
+				End:         loc[1],
+				Remediation: rule.Remediation,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,77 @@
+package detection
+
+import (
+	"math"
+	"regexp"
+)
+
+// Names of the rules Entropy reports findings under.
+const (
+	HighEntropyBase64 = "high-entropy-base64"
+	HighEntropyHex    = "high-entropy-hex"
+)
+
+// entropyRemediation describes how to handle an entropy finding, which may
+// or may not be a secret.
+const entropyRemediation = "Check whether the string is a secret; if so, revoke it and load its replacement from the environment or a secrets manager."
+
+var (
+	base64Run = regexp.MustCompile(`[A-Za-z0-9+/_-]+={0,2}`)
+	hexRun    = regexp.MustCompile(`^[0-9A-Fa-f]+$`)
+)
+
+// Entropy is a Detector flagging strings of at least MinLength base64 or hex
+// characters whose Shannon entropy, in bits per character, is at least the
+// threshold for their charset. Strings consisting only of hex characters are
+// judged as hex, since their entropy can be at most 4.
+type Entropy struct {
+	Base64Threshold float64
+	HexThreshold    float64
+	MinLength       int
+}
+
+func (Entropy) Name() string { return "entropy" }
+
+// Scan returns the high-entropy strings in data.
+func (e Entropy) Scan(data []byte) (findings []Finding) {
+	for _, loc := range base64Run.FindAllIndex(data, -1) {
+		s := data[loc[0]:loc[1]]
+		if len(s) < e.MinLength {
+			continue
+		}
+		rule, threshold := HighEntropyBase64, e.Base64Threshold
+		if hexRun.Match(s) {
+			rule, threshold = HighEntropyHex, e.HexThreshold
+		}
+		if ShannonEntropy(s) >= threshold {
+			findings = append(findings, Finding{
+				Rule:        rule,
+				Start:       loc[0],
+				End:         loc[1],
+				Remediation: entropyRemediation,
+			})
+		}
+	}
+	return findings
+}
+
+// ShannonEntropy returns the Shannon entropy of s in bits per byte.
+func ShannonEntropy(s []byte) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range s {
+		counts[b]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,64 @@
+package detection
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	cases := []struct {
+		s    string
+		want float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"ab", 1},
+		{"abcd", 2},
+		{"0123456789abcdef", 4},
+	}
+	for _, c := range cases {
+		if got := ShannonEntropy([]byte(c.s)); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("ShannonEntropy(%q) = %v, want %v", c.s, got, c.want)
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	e := Entropy{Base64Threshold: 4.5, HexThreshold: 3, MinLength: 20}
+	cases := []struct {
+		data string
+		// Rule of the one finding expected, at [start, end), or empty for
+		// none.
+		rule       string
+		start, end int
+	}{
+		// Random base64 is judged against the base64 threshold.
+		{`key = "q8Zt3XmLw9Rk2VbN7YpJc4Hs6DfGa1Ue5Ti0Oy+/"`, HighEntropyBase64, 7, 47},
+		// Strings only of hex digits are judged as hex, which could never
+		// reach the base64 threshold.
+		{"sum 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b", HighEntropyHex, 4, 44},
+		// Padding is part of the string.
+		{"q8Zt3XmLw9Rk2VbN7YpJc4Hs6DfGa1U==", HighEntropyBase64, 0, 33},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "", 0, 0},
+		{"the_quick_brown_fox_jumps_over_the_lazy_dog", "", 0, 0},
+		// One character short of MinLength.
+		{"q8Zt3XmLw9Rk2VbN7Yp", "", 0, 0},
+	}
+	for _, c := range cases {
+		findings := e.Scan([]byte(c.data))
+		if c.rule == "" {
+			if len(findings) != 0 {
+				t.Errorf("%q: got %+v, want no findings", c.data, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 {
+			t.Errorf("%q: got %d findings, want 1", c.data, len(findings))
+			continue
+		}
+		f := findings[0]
+		if f.Rule != c.rule || f.Start != c.start || f.End != c.end {
+			t.Errorf("%q: got %s [%d, %d), want %s [%d, %d)", c.data, f.Rule, f.Start, f.End, c.rule, c.start, c.end)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package detection
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// GCPServiceAccountKey is the name of the rule GCPDetector reports
+// findings under.
+const GCPServiceAccountKey = "gcp-service-account-key"
+
+var gcpTypePattern = regexp.MustCompile(`"type"\s*:\s*"service_account"`)
+
+// Most opening braces before a "type" field tried as the start of its
+// object.
+const maxGCPObjectStarts = 8
+
+// GCPDetector is a Detector flagging GCP service account key files by their
+// JSON structure: an object with type "service_account", a private_key, and
+// a client_email. Findings cover the whole object and carry the service
+// account's email and project in Details.
+type GCPDetector struct{}
+
+func (GCPDetector) Name() string { return "gcp" }
+
+func (GCPDetector) Scan(data []byte) (findings []Finding) {
+	for _, loc := range gcpTypePattern.FindAllIndex(data, -1) {
+		start := loc[0]
+		for i := 0; i < maxGCPObjectStarts; i++ {
+			if start = bytes.LastIndexByte(data[:start], '{'); start < 0 {
+				break
+			}
+			end := jsonObjectEnd(data, start)
+			if end < loc[1] {
+				continue
+			}
+			var key struct {
+				Type         string `json:"type"`
+				ProjectID    string `json:"project_id"`
+				PrivateKey   string `json:"private_key"`
+				ClientEmail  string `json:"client_email"`
+				PrivateKeyID string `json:"private_key_id"`
+			}
+			if json.Unmarshal(data[start:end], &key) != nil || key.Type != "service_account" {
+				continue
+			}
+			if key.PrivateKey != "" && key.ClientEmail != "" {
+				findings = append(findings, Finding{
+					Rule:        GCPServiceAccountKey,
+					Start:       start,
+					End:         end,
+					Remediation: "Delete the key in the service account's IAM keys page, audit its use in Cloud Audit Logs, and use workload identity instead of key files.",
+					Details: map[string]string{
+						"client_email":   key.ClientEmail,
+						"project_id":     key.ProjectID,
+						"private_key_id": key.PrivateKeyID,
+					},
+				})
+			}
+			break
+		}
+	}
+	return findings
+}
+
+// jsonObjectEnd returns the offset just past the end of the JSON object
+// starting at data[start], or -1 if it does not end.
+func jsonObjectEnd(data []byte, start int) int {
+	depth, inString := 0, false
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+package detection
+
+import (
+	"hash/crc32"
+	"regexp"
+	"strings"
+)
+
+// GitHubToken is the name of the rule GitHubTokenDetector reports findings
+// under.
+const GitHubToken = "github-token"
+
+// Base62Digits are the digits of base62, in the order GitHub encodes token
+// checksums with.
+const Base62Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	// Tokens with a prefix naming their type, then 30 random characters and
+	// a 6-character checksum of them. Submatches are the type letter, the
+	// random part, and the checksum.
+	githubTokenPattern = regexp.MustCompile(`\bgh([pousr])_([A-Za-z0-9]{30})([A-Za-z0-9]{6})\b`)
+	// Fine-grained personal access tokens, whose checksum is undocumented.
+	githubPATPattern = regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9]{22}_[A-Za-z0-9]{59}\b`)
+
+	// Token types by prefix letter.
+	githubTokenTypes = map[string]string{
+		"p": "personal-access-token",
+		"o": "oauth-access-token",
+		"u": "user-to-server-token",
+		"s": "server-to-server-token",
+		"r": "refresh-token",
+	}
+)
+
+// GitHubTokenDetector is a Detector flagging GitHub tokens: personal access
+// tokens, classic and fine-grained, OAuth and GitHub App tokens, and refresh
+// tokens. Tokens of the classic format end in a CRC32 checksum of their random
+// part, so made-up ones, such as placeholders in docs, are told apart and
+// skipped. Findings carry the token type in Details.
+type GitHubTokenDetector struct{}
+
+func (GitHubTokenDetector) Name() string { return "github" }
+
+func (GitHubTokenDetector) Scan(data []byte) (findings []Finding) {
+	add := func(start, end int, tokenType string) {
+		findings = append(findings, Finding{
+			Rule:        GitHubToken,
+			Start:       start,
+			End:         end,
+			Remediation: "Revoke the token in the owner's GitHub developer settings, or the app's installation, and review the audit log for its use.",
+			Details:     map[string]string{"type": tokenType},
+		})
+	}
+	for _, m := range githubTokenPattern.FindAllSubmatchIndex(data, -1) {
+		if githubChecksum(string(data[m[4]:m[5]])) != string(data[m[6]:m[7]]) {
+			continue
+		}
+		add(m[0], m[1], githubTokenTypes[string(data[m[2]:m[3]])])
+	}
+	for _, loc := range githubPATPattern.FindAllIndex(data, -1) {
+		add(loc[0], loc[1], "fine-grained-personal-access-token")
+	}
+	return findings
+}
+
+// githubChecksum returns the checksum GitHub appends to the random part of a
+// token: its CRC32 in base62, padded with zeros to 6 digits.
+func githubChecksum(random string) string {
+	n := crc32.ChecksumIEEE([]byte(random))
+	var digits []byte
+	for ; n > 0; n /= 62 {
+		digits = append([]byte{Base62Digits[n%62]}, digits...)
+	}
+	s := string(digits)
+	return strings.Repeat("0", 6-len(s)) + s
+}
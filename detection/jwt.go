@@ -0,0 +1,70 @@
+package detection
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// JWT is the name of the rule JWTDetector reports findings under.
+const JWT = "jwt"
+
+// Header and payload are base64url-encoded JSON objects, so both start with
+// "eyJ", the encoding of `{"`. The signature is empty for unsigned tokens.
+var jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]*`)
+
+// JWTDetector is a Detector flagging JSON Web Tokens whose header and payload
+// decode. Findings carry the token's issuer, its expiry, and whether it has
+// expired in Details, so live tokens can be triaged first. Tokens without an
+// expiry never expire.
+type JWTDetector struct{}
+
+func (JWTDetector) Name() string { return "jwt" }
+
+func (JWTDetector) Scan(data []byte) (findings []Finding) {
+	for _, loc := range jwtPattern.FindAllIndex(data, -1) {
+		parts := strings.SplitN(string(data[loc[0]:loc[1]]), ".", 3)
+		var header struct {
+			Alg string `json:"alg"`
+		}
+		var claims struct {
+			Issuer    string   `json:"iss"`
+			ExpiresAt *float64 `json:"exp"`
+		}
+		if decodeJWTPart(parts[0], &header) != nil || header.Alg == "" ||
+			decodeJWTPart(parts[1], &claims) != nil {
+			continue
+		}
+
+		details := map[string]string{"expired": "false"}
+		if claims.Issuer != "" {
+			details["issuer"] = claims.Issuer
+		}
+		if claims.ExpiresAt != nil {
+			exp := time.Unix(int64(*claims.ExpiresAt), 0).UTC()
+			details["expires_at"] = exp.Format(time.RFC3339)
+			if time.Now().After(exp) {
+				details["expired"] = "true"
+			}
+		}
+		findings = append(findings, Finding{
+			Rule:        JWT,
+			Start:       loc[0],
+			End:         loc[1],
+			Remediation: "Rotate the issuer's signing key or revoke the token's session, and have clients obtain tokens at run time.",
+			Details:     details,
+		})
+	}
+	return findings
+}
+
+// decodeJWTPart decodes the base64url-encoded JSON part of a JWT into v.
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
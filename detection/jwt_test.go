@@ -0,0 +1,72 @@
+package detection
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// jwtOf returns a JWT of the JSON objects header and claims, with signature
+// sig.
+func jwtOf(header, claims, sig string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(claims)) + "." + sig
+}
+
+func TestJWTDetector(t *testing.T) {
+	cases := []struct {
+		name  string
+		token string
+		found bool
+	}{
+		{"signed", jwtOf(`{"alg":"HS256","typ":"JWT"}`, `{"sub":"1234567890","iss":"acme"}`, "SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"), true},
+		{"unsigned", jwtOf(`{"alg":"none"}`, `{"sub":"1234567890"}`, ""), true},
+		{"header without alg", jwtOf(`{"typ":"JWT"}`, `{"sub":"1234567890"}`, "sig"), false},
+		{"payload not JSON", "eyJhbGciOiJIUzI1NiJ9.eyJub3QganNvbg.sig", false},
+	}
+	for _, c := range cases {
+		const prefix = "Authorization: Bearer "
+		findings := JWTDetector{}.Scan([]byte(prefix + c.token + "\n"))
+		if !c.found {
+			if len(findings) != 0 {
+				t.Errorf("%s: got %+v, want no findings", c.name, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 {
+			t.Errorf("%s: got %d findings, want 1", c.name, len(findings))
+			continue
+		}
+		if f := findings[0]; f.Start != len(prefix) || f.End != len(prefix)+len(c.token) {
+			t.Errorf("%s: got [%d, %d), want the token at [%d, %d)", c.name, f.Start, f.End, len(prefix), len(prefix)+len(c.token))
+		}
+	}
+}
+
+func TestJWTDetectorDetails(t *testing.T) {
+	cases := []struct {
+		name   string
+		claims string
+		want   map[string]string
+	}{
+		{"no expiry", `{"iss":"acme"}`, map[string]string{"issuer": "acme", "expired": "false"}},
+		{"expired", `{"iss":"acme","exp":1}`, map[string]string{"issuer": "acme", "expires_at": "1970-01-01T00:00:01Z", "expired": "true"}},
+		{"not expired", `{"exp":32503680000}`, map[string]string{"expires_at": "3000-01-01T00:00:00Z", "expired": "false"}},
+	}
+	for _, c := range cases {
+		findings := JWTDetector{}.Scan([]byte(jwtOf(`{"alg":"HS256"}`, c.claims, "sig")))
+		if len(findings) != 1 {
+			t.Errorf("%s: got %d findings, want 1", c.name, len(findings))
+			continue
+		}
+		got := findings[0].Details
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got details %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for k, v := range c.want {
+			if got[k] != v {
+				t.Errorf("%s: Details[%q] = %q, want %q", c.name, k, got[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package detection
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// KeywordAssignment is the name of the rule Keyword reports findings under.
+const KeywordAssignment = "keyword-assignment"
+
+// Words naming secrets in identifiers.
+const secretKeywords = `(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)`
+
+var (
+	// An identifier containing a secret keyword, assigned a quoted or bare
+	// value with =, :, :=, or =>. Submatches are the identifier and the
+	// double-quoted, single-quoted, or bare value.
+	keywordAssignment = regexp.MustCompile(`(?i)([\w.-]*` + secretKeywords + `[\w.-]*)["']?[ \t]*(?::=|=>|[:=])[ \t]*` +
+		`(?:"([^"\n]*)"|'([^'\n]*)'|([^\s,;'"#()\[\]{}]+))`)
+
+	// Values that reference a secret rather than contain it: variables,
+	// templates, placeholders, calls, names of constants or fields, and URLs.
+	keywordReference = regexp.MustCompile(`^(\$|%|<|\{|\[)|\(|^[A-Z][A-Z0-9_]*$|^[A-Za-z_]\w*(\.[A-Za-z_]\w*)+$|^[a-z][a-z0-9+.-]*://|^(?i:none|null|nil|true|false|undefined|changeme|password|secret|example\w*|dummy\w*|test\w*)$`)
+
+	// Identifiers naming facts about a secret rather than the secret, ex.
+	// token_uri or secret_name.
+	keywordMetadata = regexp.MustCompile(`[_.-](?i:id|name|uri|url|path|file|type|field|len|length|hint|label|count|max|min)$|` +
+		`[a-z0-9](Id|ID|Name|Uri|URI|Url|URL|Path|File|Type|Field|Len|Length|Hint|Label|Count|Max|Min)$`)
+
+	// A key containing a secret keyword.
+	secretKey = regexp.MustCompile(`(?i)` + secretKeywords)
+)
+
+// SecretKey reports whether key, the name of a field or setting, names a
+// secret rather than a fact about one, as Keyword's identifiers do.
+func SecretKey(key string) bool {
+	return secretKey.MatchString(key) && !keywordMetadata.MatchString(key)
+}
+
+// SecretReference reports whether value references a secret, as variables,
+// templates, and placeholders do, rather than containing it.
+func SecretReference(value string) bool {
+	return keywordReference.MatchString(value)
+}
+
+// Keyword is a Detector flagging values assigned to identifiers named like
+// secrets, ex. password = "...", api_key: ..., or secret=..., that look
+// random: at least MinLength characters, from at least two character classes
+// of lower case, upper case, digits, and symbols, with Shannon entropy of at
+// least MinEntropy bits per character. References to secrets, such as
+// environment variables and template placeholders, are not flagged.
+type Keyword struct {
+	MinEntropy float64
+	MinLength  int
+}
+
+func (Keyword) Name() string { return "keyword" }
+
+// Scan returns the assignments of random-looking values to secret-named
+// identifiers in data.
+func (k Keyword) Scan(data []byte) (findings []Finding) {
+	for _, m := range keywordAssignment.FindAllSubmatchIndex(data, -1) {
+		var value string
+		for g := 4; g < len(m); g += 2 {
+			if m[g] >= 0 {
+				value = string(data[m[g]:m[g+1]])
+				break
+			}
+		}
+		// Bare values followed by "(" or "[" are calls and lookups.
+		if m[8] >= 0 && m[1] < len(data) && (data[m[1]] == '(' || data[m[1]] == '[') {
+			continue
+		}
+		if keywordMetadata.Match(data[m[2]:m[3]]) {
+			continue
+		}
+		if len(value) < k.MinLength || keywordReference.MatchString(value) ||
+			charClasses(value) < 2 || ShannonEntropy([]byte(value)) < k.MinEntropy {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:        KeywordAssignment,
+			Start:       m[0],
+			End:         m[1],
+			Remediation: "Revoke or change the credential, and load its replacement from the environment or a secrets manager.",
+			Details:     map[string]string{"key": string(data[m[2]:m[3]])},
+		})
+	}
+	return findings
+}
+
+// charClasses returns how many of lower case letters, upper case letters,
+// digits, and other characters s contains.
+func charClasses(s string) (n int) {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			other = true
+		}
+	}
+	for _, b := range []bool{lower, upper, digit, other} {
+		if b {
+			n++
+		}
+	}
+	return n
+}
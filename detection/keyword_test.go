@@ -0,0 +1,85 @@
+package detection
+
+import (
+	"testing"
+)
+
+func TestKeyword(t *testing.T) {
+	k := Keyword{MinEntropy: 3, MinLength: 8}
+	cases := []struct {
+		data string
+		// The assignment expected to be flagged in data, and the identifier
+		// assigned, or empty if nothing should be flagged.
+		match, key string
+	}{
+		{`api_key = "Zx9kQ2mPv7Lw"`, `api_key = "Zx9kQ2mPv7Lw"`, "api_key"},
+		{"  db_password: Zx9kQ2mPv7Lw\n", "db_password: Zx9kQ2mPv7Lw", "db_password"},
+		{`secret := 'Zx9kQ2mPv7Lw'`, `secret := 'Zx9kQ2mPv7Lw'`, "secret"},
+		{`{"clientSecret": "Zx9kQ2mPv7Lw"}`, `clientSecret": "Zx9kQ2mPv7Lw"`, "clientSecret"},
+		{`'auth.token' => 'Zx9kQ2mPv7Lw',`, `auth.token' => 'Zx9kQ2mPv7Lw'`, "auth.token"},
+		// References to secrets.
+		{`password = "${DB_PASSWORD}"`, "", ""},
+		{`token = os.Getenv("TOKEN")`, "", ""},
+		{`token = config.auth.token`, "", ""},
+		{`password: DB_PASSWORD`, "", ""},
+		// Facts about secrets.
+		{`token_uri = "https://oauth2.example.com/token"`, "", ""},
+		{`secretName: "Zx9kQ2mPv7Lw"`, "", ""},
+		// Values that do not look random.
+		{`password = "abcdefghijkl"`, "", ""},
+		{`password = "Zx9kQ"`, "", ""},
+	}
+	for _, c := range cases {
+		findings := k.Scan([]byte(c.data))
+		if c.match == "" {
+			if len(findings) != 0 {
+				t.Errorf("%s: got %+v, want no findings", c.data, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 {
+			t.Errorf("%s: got %d findings, want 1", c.data, len(findings))
+			continue
+		}
+		f := findings[0]
+		if got := c.data[f.Start:f.End]; got != c.match {
+			t.Errorf("%s: flagged %q, want %q", c.data, got, c.match)
+		}
+		if f.Details["key"] != c.key {
+			t.Errorf("%s: got key %q, want %q", c.data, f.Details["key"], c.key)
+		}
+	}
+}
+
+func TestSecretKey(t *testing.T) {
+	cases := map[string]bool{
+		"password":      true,
+		"apiKey":        true,
+		"client_secret": true,
+		"secret_name":   false,
+		"tokenURL":      false,
+		"username":      false,
+	}
+	for key, want := range cases {
+		if got := SecretKey(key); got != want {
+			t.Errorf("SecretKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestSecretReference(t *testing.T) {
+	cases := map[string]bool{
+		"$DB_PASSWORD":      true,
+		"{{ .Values.pw }}":  true,
+		"<your-token>":      true,
+		"DB_PASSWORD":       true,
+		"settings.database": true,
+		"changeme":          true,
+		"Zx9kQ2mPv7Lw":      false,
+	}
+	for value, want := range cases {
+		if got := SecretReference(value); got != want {
+			t.Errorf("SecretReference(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
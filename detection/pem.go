@@ -0,0 +1,48 @@
+package detection
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// PrivateKey is the name of the rule PEMDetector reports findings under.
+const PrivateKey = "private-key"
+
+var (
+	pemBeginPattern = regexp.MustCompile(`-----BEGIN ((?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?)-----`)
+	pemBodyPattern  = regexp.MustCompile(`[A-Za-z0-9+/]`)
+)
+
+// PEMDetector is a Detector flagging PEM-armored private keys: PKCS#1 RSA,
+// EC, DSA, PKCS#8, OpenSSH, and PGP. Findings cover the whole block from its
+// BEGIN line to its END line, so a block indented in YAML or with escaped
+// newlines in JSON is reported as one range. A block with no END line, as in
+// a truncated paste, is reported from BEGIN to the end of data.
+type PEMDetector struct{}
+
+func (PEMDetector) Name() string { return "pem" }
+
+func (PEMDetector) Scan(data []byte) (findings []Finding) {
+	for _, loc := range pemBeginPattern.FindAllSubmatchIndex(data, -1) {
+		if len(findings) != 0 && loc[0] < findings[len(findings)-1].End {
+			continue
+		}
+		bodyEnd, end := len(data), len(data)
+		endLine := []byte("-----END " + string(data[loc[2]:loc[3]]) + "-----")
+		if i := bytes.Index(data[loc[1]:], endLine); i >= 0 {
+			bodyEnd = loc[1] + i
+			end = bodyEnd + len(endLine)
+		}
+		// Skip empty placeholder blocks, as in documentation.
+		if !pemBodyPattern.Match(bytes.Replace(data[loc[1]:bodyEnd], []byte(`\n`), nil, -1)) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:        PrivateKey,
+			Start:       loc[0],
+			End:         end,
+			Remediation: "Revoke the key and any certificates issued for it, replace it wherever it is trusted, and load the new key from a secrets manager.",
+		})
+	}
+	return findings
+}
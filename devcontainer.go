@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// Rule of secrets set in dev container configs.
+const devcontainerSecret = "devcontainer-secret"
+
+var (
+	// A dev container config: .devcontainer.json, or devcontainer.json in
+	// .devcontainer or one of its subdirectories.
+	devcontainerFile = regexp.MustCompile(`(^|/)(\.devcontainer\.json|\.devcontainer/([^/]+/)?devcontainer\.json)$`)
+	// Key paths of a config's variables, build args, docker run arguments,
+	// and lifecycle commands. Submatches are the property and, for
+	// variables and build args, the variable.
+	devcontainerVarPath = regexp.MustCompile(`^(containerEnv|remoteEnv|build\.args)\.(.+)$`)
+	devcontainerRunArg  = regexp.MustCompile(`^runArgs\[\d+\]$`)
+	devcontainerCommand = regexp.MustCompile(`^(initializeCommand|onCreateCommand|updateContentCommand|postCreateCommand|postStartCommand|postAttachCommand)(\.[^.\[]+)?(\[\d+\])?$`)
+	// A GitHub repo, as owner/name or a URL.
+	githubRepoRef = regexp.MustCompile(`^(?:https://github\.com/)?([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+)
+
+// Lifecycle commands prebuilds run, whose effects are kept in every
+// codespace created from the prebuild.
+var prebuildCommands = map[string]bool{
+	"onCreateCommand":      true,
+	"updateContentCommand": true,
+}
+
+// isDevcontainer reports whether the file at the slash-separated relPath is a
+// dev container config, as Codespaces and VS Code read.
+func isDevcontainer(relPath string) bool {
+	return devcontainerFile.MatchString(relPath)
+}
+
+// jsoncData returns a copy of data, JSON with comments such as dev container
+// configs are, with comments and trailing commas replaced by spaces so it
+// parses as JSON at the same offsets. Newlines are kept.
+func jsoncData(data []byte) []byte {
+	out := append([]byte(nil), data...)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := i
+			for end < len(out) && out[end] != '\n' {
+				end++
+			}
+			blank(i, end)
+			i = end
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := i + 2
+			for end+1 < len(out) && !(out[end] == '*' && out[end+1] == '/') {
+				end++
+			}
+			end += 2
+			if end > len(out) {
+				end = len(out)
+			}
+			blank(i, end)
+			i = end - 1
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			lastComma = -1
+		}
+	}
+	return out
+}
+
+// devcontainerValues returns the variables set by the file at relPath, given
+// values, its scalar values, if it is a dev container config: containerEnv,
+// remoteEnv, build args, and environment variables and build args passed in
+// runArgs as -e KEY=VALUE or --build-arg KEY=VALUE. Lifecycle commands are
+// returned with empty keys, so findings in them are labeled with the command.
+// Instructions are the property, ex. containerEnv; Lines are not set.
+func devcontainerValues(relPath string, values []structValue) (dv []dockerValue) {
+	if !isDevcontainer(relPath) {
+		return nil
+	}
+	for i, v := range values {
+		switch {
+		case devcontainerVarPath.MatchString(v.Path):
+			m := devcontainerVarPath.FindStringSubmatch(v.Path)
+			v.Key = m[2]
+			dv = append(dv, dockerValue{structValue: v, Instruction: m[1], BuildArg: m[1] == "build.args"})
+		case devcontainerRunArg.MatchString(v.Path):
+			// Flags and their values are separate arguments, or joined by =.
+			flag, arg := v.Value, ""
+			if eq := strings.IndexByte(v.Value, '='); eq >= 0 && strings.HasPrefix(v.Value, "--") {
+				flag, arg = v.Value[:eq], v.Value[eq+1:]
+				v.Start += eq + 1
+			} else if i+1 < len(values) && devcontainerRunArg.MatchString(values[i+1].Path) {
+				v = values[i+1]
+				arg = v.Value
+			}
+			if flag != "-e" && flag != "--env" && flag != "--build-arg" {
+				continue
+			}
+			eq := strings.IndexByte(arg, '=')
+			if eq < 0 || v.End-v.Start != len(arg) {
+				continue
+			}
+			v.Key, v.Value = arg[:eq], arg[eq+1:]
+			v.Start += eq + 1
+			dv = append(dv, dockerValue{structValue: v, Instruction: "runArgs", BuildArg: flag == "--build-arg"})
+		case devcontainerCommand.MatchString(v.Path):
+			v.Key = ""
+			dv = append(dv, dockerValue{structValue: v, Instruction: devcontainerCommand.FindStringSubmatch(v.Path)[1]})
+		}
+	}
+	return dv
+}
+
+// findDevcontainer returns the positions of secrets among dv, the values of a
+// dev container config, as findDocker finds them.
+func findDevcontainer(dv []dockerValue, positions []SensitivePos) []SensitivePos {
+	found := findDocker(dv, positions)
+	for i := range found {
+		found[i].Rule = devcontainerSecret
+		found[i].Remediation = "Revoke or change the credential, and provide it as a Codespaces secret, listed under the config's secrets property, instead."
+	}
+	return found
+}
+
+// labelDevcontainer adds the property of the value of dv each of positions
+// overlaps, if any, to its Details as property, and marks findings in
+// commands prebuilds run with prebuild.
+func labelDevcontainer(dv []dockerValue, positions []SensitivePos) {
+	for _, v := range dv {
+		for i := range positions {
+			pos := &positions[i]
+			if !overlapsAny(positions[i:i+1], v.Start, v.End) {
+				continue
+			}
+			if pos.Details == nil {
+				pos.Details = map[string]string{}
+			}
+			pos.Details["property"] = v.Instruction
+			if prebuildCommands[v.Instruction] {
+				pos.Details["prebuild"] = "true"
+			}
+		}
+	}
+}
+
+// devcontainerDotfiles returns the GitHub repos, as owner/name, that the dev
+// container configs in fs name as their dotfiles repository, sorted.
+func devcontainerDotfiles(fs billy.Filesystem) (repos []string) {
+	configs := []string{".devcontainer.json", ".devcontainer/devcontainer.json"}
+	if infos, err := fs.ReadDir(".devcontainer"); err == nil {
+		for _, info := range infos {
+			if info.IsDir() {
+				configs = append(configs, path.Join(".devcontainer", info.Name(), "devcontainer.json"))
+			}
+		}
+	}
+	for _, config := range configs {
+		data, err := readFSFile(fs, config)
+		if err != nil {
+			continue
+		}
+		values, err := jsonValues(jsoncData(data))
+		if err != nil {
+			continue
+		}
+		for _, v := range values {
+			if v.Key != "dotfiles.repository" {
+				continue
+			}
+			if m := githubRepoRef.FindStringSubmatch(strings.TrimSpace(v.Value)); m != nil {
+				repos = appendUnique(repos, m[1]+"/"+m[2])
+			}
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// dotfilesRepos returns the repos named in sr's Dotfiles that are not among
+// scanned, the full names of repos already queued for scanning. Repos that
+// cannot be fetched are recorded as errors of sr.
+func dotfilesRepos(ctx context.Context, client *github.Client, sr *SensitiveRepo, scanned map[string]bool) (repos []*github.Repository) {
+	for _, fullName := range sr.Dotfiles {
+		if scanned[strings.ToLower(fullName)] {
+			continue
+		}
+		scanned[strings.ToLower(fullName)] = true
+		owner, name, _ := splitRepoFullName(fullName)
+		repo, _, err := client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			sr.addError("dotfiles", fullName, err)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/github"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Most commits and files the compare API lists. Forks diverging further are
+// checked in full.
+const (
+	maxCompareCommits = 250
+	maxCompareFiles   = 300
+)
+
+// divergence is how a fork differs from its parent: the commits it has that
+// the parent's default branch lacks, and the files they change.
+type divergence struct {
+	Commits []string
+	Files   map[string]bool
+}
+
+// forkDivergence compares repo, a fork owned by owner, with the default
+// branch of the repo it was forked from. ok is false if the fork diverges
+// too far for the comparison to be complete.
+func forkDivergence(ctx context.Context, client *github.Client, owner string, repo *github.Repository) (d divergence, ok bool, err error) {
+	parent, err := repoParent(ctx, client, owner, repo)
+	if err != nil {
+		return d, false, err
+	}
+	if parent == nil {
+		return d, false, fmt.Errorf("%s is not a fork", repo.GetName())
+	}
+	head := fmt.Sprintf("%s:%s", owner, repo.GetDefaultBranch())
+	cmp, _, err := client.Repositories.CompareCommits(ctx, parent.GetOwner().GetLogin(), parent.GetName(), parent.GetDefaultBranch(), head)
+	if err != nil {
+		return d, false, err
+	}
+	if cmp.GetAheadBy() > len(cmp.Commits) || len(cmp.Commits) >= maxCompareCommits || len(cmp.Files) >= maxCompareFiles {
+		return d, false, nil
+	}
+
+	d.Files = make(map[string]bool, len(cmp.Files))
+	for _, f := range cmp.Files {
+		if f.GetStatus() != "removed" {
+			d.Files[f.GetFilename()] = true
+		}
+	}
+	for _, c := range cmp.Commits {
+		d.Commits = append(d.Commits, c.GetSHA())
+	}
+	return d, true, nil
+}
+
+// scanDivergentCommits checks the files each of commits in r adds or changes
+// as of that commit, and returns those with sensitive data, so secrets
+// committed to a fork and later removed are still found. Versions of files
+// identical to the checkout of HEAD are skipped, as the checkout is checked
+// separately. Commits missing from r, as in shallow clones, are skipped.
+func scanDivergentCommits(r *git.Repository, commits []string) (files []SensitiveFile, err error) {
+	seen := make(map[plumbing.Hash]bool)
+	if head, err := r.Head(); err == nil {
+		if c, err := r.CommitObject(head.Hash()); err == nil {
+			if tree, err := c.Tree(); err == nil {
+				tree.Files().ForEach(func(f *object.File) error {
+					seen[f.Hash] = true
+					return nil
+				})
+			}
+		}
+	}
+
+	for _, sha := range commits {
+		c, err := r.CommitObject(plumbing.NewHash(sha))
+		if err == plumbing.ErrObjectNotFound {
+			continue
+		}
+		if err != nil {
+			return files, err
+		}
+		tree, err := c.Tree()
+		if err != nil {
+			return files, err
+		}
+		var parentTree *object.Tree
+		if parent, err := c.Parent(0); err == nil {
+			if parentTree, err = parent.Tree(); err != nil {
+				return files, err
+			}
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return files, err
+		}
+		for _, change := range changes {
+			if change.To.Name == "" || seen[change.To.TreeEntry.Hash] {
+				continue
+			}
+			seen[change.To.TreeEntry.Hash] = true
+			f, err := tree.TreeEntryFile(&change.To.TreeEntry)
+			if err != nil {
+				return files, err
+			}
+			contents, err := f.Contents()
+			if err != nil {
+				return files, err
+			}
+			if positions := scanFileData(change.To.Name, []byte(contents)); positions != nil {
+				captureContext(positions, []byte(contents))
+				files = append(files, SensitiveFile{
+					Path:      change.To.Name,
+					Commit:    sha,
+					Positions: positions,
+				})
+			}
+		}
+	}
+	return files, nil
+}
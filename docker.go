@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// Rules of secrets set by Dockerfile ENV and ARG instructions, and by
+// docker-compose environment and build args.
+const (
+	dockerfileSecret = "dockerfile-secret"
+	composeSecret    = "compose-secret"
+)
+
+// Shortest build arg value of any name flagged for looking like a token, and
+// the least Shannon entropy, in bits per character, it must have. Hex digests
+// fall short of the entropy.
+const (
+	minBuildArgToken        = 20
+	minBuildArgTokenEntropy = 4.0
+)
+
+var (
+	// A compose file name: compose.yaml, docker-compose.yml, or an override
+	// such as docker-compose.prod.yml.
+	composeFile = regexp.MustCompile(`^(docker-)?compose(\.[\w.-]+)?\.ya?ml$`)
+	// Key paths of compose services' environment variables and build args,
+	// as maps or lists. Submatches are the service, the block, and the
+	// variable if the block is a map. Version 1 files have no services key.
+	composeVarPath = regexp.MustCompile(`^(?:services\.)?([^.\[]+)\.(environment|build\.args)(?:\.([^.\[]+)|\[\d+\])$`)
+	// Build arg names of checksums and versions, whose values look random
+	// but are public.
+	buildArgPublic = regexp.MustCompile(`(?i)(^|[_.-])(sha\d*|checksum|sum|digest|hash|version|commit)($|[_.-])`)
+)
+
+// dockerValue is a variable set by a Dockerfile instruction, or in a compose
+// service's environment or build args: the instruction, ex. ENV, or compose
+// block, ex. environment, the 1-based line it starts on, and for compose
+// files the service. BuildArg is set for ARG instructions and build args.
+type dockerValue struct {
+	structValue
+	Instruction string
+	Line        int
+	Service     string
+	BuildArg    bool
+}
+
+// isDockerfile reports whether the file at the slash-separated relPath is a
+// Dockerfile, ex. Dockerfile, Dockerfile.dev, app.dockerfile, or
+// Containerfile.
+func isDockerfile(relPath string) bool {
+	base := path.Base(relPath)
+	return base == "Dockerfile" || base == "Containerfile" || strings.HasPrefix(base, "Dockerfile.") ||
+		strings.HasSuffix(strings.ToLower(base), ".dockerfile")
+}
+
+// dockerValues returns the variables set in fileData, a file at relPath, if it
+// is a Dockerfile, or if it is a compose file, of which values are the scalar
+// values.
+func dockerValues(relPath string, fileData []byte, values []structValue) []dockerValue {
+	switch {
+	case isDockerfile(relPath):
+		return dockerfileValues(fileData)
+	case composeFile.MatchString(path.Base(relPath)):
+		return composeValues(fileData, values)
+	}
+	return nil
+}
+
+// dockerfileValues returns the variables set by the ENV and ARG instructions
+// of data, a Dockerfile. Instructions may continue across lines ending in \,
+// and set several variables as key=value pairs, or ENV one as key value. ARGs
+// without a default are skipped.
+func dockerfileValues(data []byte) (dv []dockerValue) {
+	line := 0
+	for off := 0; off < len(data); {
+		// Find the end of the instruction, past continued lines. Comments
+		// do not continue.
+		start, end := off, off
+		comment := bytes.HasPrefix(bytes.TrimLeft(data[off:], " \t"), []byte("#"))
+		for {
+			lineEnd := bytes.IndexByte(data[end:], '\n')
+			if lineEnd < 0 {
+				end = len(data)
+				break
+			}
+			end += lineEnd
+			if comment || !bytes.HasSuffix(bytes.TrimRight(data[off:end], " \t\r"), []byte(`\`)) {
+				break
+			}
+			end++
+		}
+		off = end + 1
+		startLine := line + 1
+		line += bytes.Count(data[start:end], []byte("\n")) + 1
+
+		text := string(data[start:end])
+		content := strings.TrimLeft(text, " \t")
+		fields := strings.Fields(content)
+		if len(fields) < 2 {
+			continue
+		}
+		instruction := strings.ToUpper(fields[0])
+		if instruction != "ENV" && instruction != "ARG" {
+			continue
+		}
+		argsOff := start + len(text) - len(content) + len(fields[0])
+		for _, v := range instructionVars(data[argsOff:end], argsOff, instruction == "ENV") {
+			dv = append(dv, dockerValue{structValue: v, Instruction: instruction, Line: startLine, BuildArg: instruction == "ARG"})
+		}
+	}
+	return dv
+}
+
+// instructionVars returns the variables assigned in args, the arguments of an
+// instruction starting at offset off of its file, as key=value pairs with
+// optionally quoted values. If legacy is set and the first argument is not a
+// pair, it is a key assigned the rest of args, as ENV key value is.
+func instructionVars(args []byte, off int, legacy bool) (values []structValue) {
+	// Continuations separate arguments as whitespace does.
+	isSep := func(c byte) bool { return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\\' }
+	for i := 0; i < len(args); {
+		if isSep(args[i]) {
+			i++
+			continue
+		}
+		keyStart := i
+		for i < len(args) && !isSep(args[i]) && args[i] != '=' {
+			i++
+		}
+		key := string(args[keyStart:i])
+		if i == len(args) || args[i] != '=' {
+			if legacy && len(values) == 0 {
+				rest := bytes.TrimSpace(args[i:])
+				if len(rest) != 0 {
+					vStart := off + i + bytes.Index(args[i:], rest)
+					values = append(values, structValue{Path: key, Key: key, Value: string(rest), Start: vStart, End: vStart + len(rest)})
+				}
+				return values
+			}
+			continue
+		}
+		i++
+		v := structValue{Path: key, Key: key}
+		if i < len(args) && (args[i] == '"' || args[i] == '\'') {
+			quote := args[i]
+			i++
+			v.Start = off + i
+			for i < len(args) && args[i] != quote {
+				if args[i] == '\\' && quote == '"' {
+					i++
+				}
+				i++
+			}
+			if i > len(args) {
+				i = len(args)
+			}
+			v.End = off + i
+			i++
+		} else {
+			v.Start = off + i
+			for i < len(args) && !isSep(args[i]) {
+				i++
+			}
+			v.End = off + i
+		}
+		v.Value = string(args[v.Start-off : v.End-off])
+		values = append(values, v)
+		legacy = false
+	}
+	return values
+}
+
+// composeValues returns the environment variables and build args of the
+// services of a compose file, given values, its scalar values, and data, its
+// text. List entries, ex. - DB_PASSWORD=..., are split into key and value.
+func composeValues(data []byte, values []structValue) (dv []dockerValue) {
+	for _, v := range values {
+		m := composeVarPath.FindStringSubmatch(v.Path)
+		if m == nil {
+			continue
+		}
+		if m[3] == "" {
+			// Entries whose value was unquoted or unescaped can be split in
+			// place.
+			eq := strings.IndexByte(v.Value, '=')
+			if eq < 0 || v.End-v.Start != len(v.Value) {
+				continue
+			}
+			v.Key = v.Value[:eq]
+			v.Value = v.Value[eq+1:]
+			v.Start += eq + 1
+		}
+		dv = append(dv, dockerValue{
+			structValue: v,
+			Instruction: m[2],
+			Line:        1 + bytes.Count(data[:v.Start], []byte("\n")),
+			Service:     m[1],
+			BuildArg:    m[2] == "build.args",
+		})
+	}
+	return dv
+}
+
+// findDocker returns the positions of secrets among dv: values of
+// secret-named variables, and build args that look like tokens whatever their
+// name, as those are baked into image layers. Values referencing a secret, or
+// already covered by positions, are skipped.
+func findDocker(dv []dockerValue, positions []SensitivePos) (found []SensitivePos) {
+	for _, v := range dv {
+		if v.Value == "" || detection.SecretReference(v.Value) || overlapsAny(positions, v.Start, v.End) {
+			continue
+		}
+		if !detection.SecretKey(v.Key) && !(v.BuildArg && tokenLike(v.Key, v.Value)) {
+			continue
+		}
+		pos := SensitivePos{
+			Start:       v.Start,
+			End:         v.End,
+			KeyPath:     v.Path,
+			Rule:        dockerfileSecret,
+			Remediation: "Revoke or change the credential. Values set by ENV and ARG are kept in the image's layers and history; pass secrets to builds with RUN --mount=type=secret and to containers at run time instead.",
+		}
+		if v.Service != "" {
+			pos.Rule = composeSecret
+			pos.Remediation = "Revoke or change the credential, and reference it from an untracked .env file or the shell, ex. ${DB_PASSWORD}, or use compose secrets instead."
+		}
+		found = append(found, pos)
+	}
+	return found
+}
+
+// labelDocker adds the instruction and line of the value of dv each of
+// positions overlaps, if any, and its service, to its Details as instruction,
+// line, and service.
+func labelDocker(dv []dockerValue, positions []SensitivePos) {
+	for _, v := range dv {
+		for i := range positions {
+			pos := &positions[i]
+			if !overlapsAny(positions[i:i+1], v.Start, v.End) {
+				continue
+			}
+			if pos.Details == nil {
+				pos.Details = map[string]string{}
+			}
+			pos.Details["instruction"] = v.Instruction
+			pos.Details["line"] = strconv.Itoa(v.Line)
+			if v.Service != "" {
+				pos.Details["service"] = v.Service
+			}
+		}
+	}
+}
+
+// tokenLike reports whether value, of the variable key, looks like a token:
+// long, random, and of both letters and digits. Checksums and versions are
+// not tokens.
+func tokenLike(key, value string) bool {
+	if len(value) < minBuildArgToken || strings.ContainsAny(value, " \t\r\n") || buildArgPublic.MatchString(key) {
+		return false
+	}
+	letter := strings.IndexFunc(value, unicode.IsLetter) >= 0
+	digit := strings.IndexFunc(value, unicode.IsDigit) >= 0
+	return letter && digit && detection.ShannonEntropy([]byte(value)) >= minBuildArgTokenEntropy
+}
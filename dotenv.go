@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Rule of values of secret-named variables in dotenv files.
+const dotenvSecret = "dotenv-secret"
+
+// A dotenv variable name.
+var dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// isDotenv reports whether the file at the slash-separated relPath is a
+// dotenv file: .env, .env.<environment>, <name>.env, or direnv's .envrc.
+func isDotenv(relPath string) bool {
+	base := path.Base(relPath)
+	return base == ".env" || base == ".envrc" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// dotenvValues returns the values assigned in data, a dotenv file, keyed by
+// variable name. Lines are KEY=VALUE, optionally preceded by export. Values
+// may be quoted, and double-quoted values may span lines; unquoted values
+// end at a # comment.
+func dotenvValues(data []byte) []structValue {
+	var values []structValue
+	for off := 0; off < len(data); {
+		lineEnd := bytes.IndexByte(data[off:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(data)
+		} else {
+			lineEnd += off
+		}
+		lineStart := off
+		off = lineEnd + 1
+
+		line := string(bytes.TrimRight(data[lineStart:lineEnd], " \t\r"))
+		content := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(content, "export ") {
+			content = strings.TrimLeft(content[len("export "):], " \t")
+		}
+		eq := strings.IndexByte(content, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.TrimRight(content[:eq], " \t")
+		if !dotenvKey.MatchString(key) {
+			continue
+		}
+		value := strings.TrimLeft(content[eq+1:], " \t")
+		start := lineStart + len(line) - len(value)
+
+		v := structValue{Path: key, Key: key}
+		switch {
+		case value == "":
+			continue
+		case value[0] == '"' || value[0] == '\'':
+			q := bytes.IndexByte(data[start+1:], value[0])
+			if q < 0 || value[0] == '\'' && start+1+q > lineEnd {
+				continue
+			}
+			v.Start, v.End = start+1, start+1+q
+			if v.End > lineEnd {
+				off = bytes.IndexByte(data[v.End:], '\n')
+				if off < 0 {
+					off = len(data)
+				} else {
+					off += v.End + 1
+				}
+			}
+		default:
+			if c := strings.Index(value, " #"); c >= 0 {
+				value = strings.TrimRight(value[:c], " \t")
+			}
+			v.Start, v.End = start, start+len(value)
+		}
+		v.Value = string(data[v.Start:v.End])
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// ecosystem describes the files a language or framework generates that are
+// full of hashes and third-party code, which would otherwise bury real
+// findings. A repo is in the ecosystem if any of Markers exists at its top
+// level. Files whose repo-relative, slash-separated path matches Skip are not
+// checked. In files matching DigestFiles, findings on lines matching
+// DigestLine are dropped; the rest of those files, such as registry URLs, is
+// still checked.
+type ecosystem struct {
+	Name        string
+	Markers     []string
+	Skip        *regexp.Regexp
+	DigestFiles *regexp.Regexp
+	DigestLine  *regexp.Regexp
+}
+
+var ecosystems = []ecosystem{
+	{
+		Name:        "go",
+		Markers:     []string{"go.mod", "Gopkg.toml", "glide.yaml"},
+		Skip:        regexp.MustCompile(`(^|/)vendor/`),
+		DigestFiles: regexp.MustCompile(`(^|/)go\.sum$`),
+		DigestLine:  regexp.MustCompile(`^\S+ \S+ h1:[A-Za-z0-9+/]+=*$`),
+	},
+	{
+		Name:        "javascript",
+		Markers:     []string{"package.json"},
+		Skip:        regexp.MustCompile(`(^|/)(node_modules|bower_components)/`),
+		DigestFiles: regexp.MustCompile(`(^|/)(package-lock\.json|npm-shrinkwrap\.json|yarn\.lock|pnpm-lock\.yaml)$`),
+		DigestLine:  regexp.MustCompile(`\b(integrity|checksum)\b`),
+	},
+	{
+		Name:        "python",
+		Markers:     []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+		Skip:        regexp.MustCompile(`(^|/)(\.?venv|site-packages|__pycache__)/`),
+		DigestFiles: regexp.MustCompile(`(^|/)(poetry\.lock|Pipfile\.lock|requirements[^/]*\.txt)$`),
+		DigestLine:  regexp.MustCompile(`\b(content-)?hash(es)?\b|--hash=|"sha256:`),
+	},
+}
+
+// detectEcosystems returns the ecosystems whose markers exist at the top
+// level of fs, or none if default ignores are disabled.
+func detectEcosystems(fs billy.Filesystem) (found []ecosystem) {
+	if noDefaultIgnores {
+		return nil
+	}
+	for _, eco := range ecosystems {
+		for _, marker := range eco.Markers {
+			if _, err := fs.Stat(marker); err == nil {
+				found = append(found, eco)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// ecosystemSkips reports whether any of ecos skips the file at the
+// repo-relative, slash-separated relPath.
+func ecosystemSkips(ecos []ecosystem, relPath string) bool {
+	for _, eco := range ecos {
+		if eco.Skip.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropDigests removes positions lying on digest lines of fileData, per the
+// ecosystems in ecos whose DigestFiles match relPath.
+func dropDigests(ecos []ecosystem, relPath string, fileData []byte, positions []SensitivePos) []SensitivePos {
+	for _, eco := range ecos {
+		if !eco.DigestFiles.MatchString(relPath) {
+			continue
+		}
+		kept := positions[:0]
+		for _, pos := range positions {
+			start, end := lineBounds(fileData, pos.Start, pos.End, 0)
+			if !eco.DigestLine.Match(bytes.TrimRight(fileData[start:end], "\r\n")) {
+				kept = append(kept, pos)
+			}
+		}
+		positions = kept
+	}
+	if len(positions) == 0 {
+		return nil
+	}
+	return positions
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/estroz/seekret/scanerr"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// Ops of scan errors that are failed API requests: those made to GitHub's
+// REST API or LFS server rather than to the local clone.
+var apiOps = map[string]bool{
+	"compare":    true,
+	"dotfiles":   true,
+	"exposure":   true,
+	"lfs":        true,
+	"quarantine": true,
+}
+
+// newScanError returns err, from op on path in repo, as a
+// *scanerr.CloneError, *scanerr.APIError, or *scanerr.DetectError, by op.
+// Errors already of one of those types are returned as is.
+func newScanError(repo, op, path string, err error) error {
+	var (
+		cloneErr  *scanerr.CloneError
+		apiErr    *scanerr.APIError
+		detectErr *scanerr.DetectError
+	)
+	if errors.As(err, &cloneErr) || errors.As(err, &apiErr) || errors.As(err, &detectErr) {
+		return err
+	}
+	switch {
+	case op == "clone":
+		// Missing repos and rejected credentials stay that way.
+		retriable := scanerr.Transient(err) && err != transport.ErrRepositoryNotFound &&
+			err != transport.ErrAuthenticationRequired && err != transport.ErrAuthorizationFailed
+		return &scanerr.CloneError{Repo: repo, Err: err, Retriable: retriable}
+	case apiOps[op]:
+		return &scanerr.APIError{Op: op, Repo: repo, Err: err, Retriable: scanerr.Transient(err)}
+	}
+	return &scanerr.DetectError{Op: op, Repo: repo, Path: path, Err: err, Retriable: scanerr.Transient(err)}
+}
+
+// errorSummary is a ResultWriter tallying the errors of the repos written to
+// it, so a scan can report them once it completes.
+type errorSummary struct {
+	scanerr.Summary
+	// Number of repos with errors.
+	repos int
+}
+
+func (s *errorSummary) WriteRepo(sr SensitiveRepo) error {
+	if len(sr.Errors) == 0 {
+		return nil
+	}
+	s.repos++
+	for _, e := range sr.Errors {
+		s.Add(e.Err)
+	}
+	return nil
+}
+
+// log logs the tally as a warning prefixed by prefix, if there were errors.
+func (s *errorSummary) log(prefix string) {
+	if s.Total > 0 {
+		logrus.Warnf("%s%s in %d repos", prefix, s.Summary, s.repos)
+	}
+}
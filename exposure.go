@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// Severity of findings whose secret is also in a public repo.
+const severityPublic = "critical"
+
+// Most code searches made per repo. Code search has a much lower rate limit
+// than the rest of the API.
+const maxExposureSearches = 20
+
+// Shortest prefix of a secret searched for. Only a secret's first half is
+// sent to code search, but no less than this.
+const minExposurePrefix = 8
+
+// Runs of characters secrets are made of. Shorter runs are too common to
+// search for.
+var secretTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/_\-.]{16,}={0,2}`)
+
+// checkPublicExposure searches public code on GitHub for the secret of each
+// finding in files, found in the checkout of the private repo in fs, and sets
+// Public, PublicURL, and a severity of critical on findings whose secret is
+// also in a public repo, such as one of the org's. Files found in past
+// commits are skipped. Only a prefix of each secret is sent to GitHub, as
+// searchPublicCode searches.
+func checkPublicExposure(ctx context.Context, client *github.Client, repo *github.Repository, fs billy.Filesystem, files []SensitiveFile) error {
+	// Searched secrets, mapped to the public URL they were found at, if any.
+	searched := make(map[string]string)
+	for i := range files {
+		f := &files[i]
+		if f.Commit != "" {
+			continue
+		}
+		data, err := readFSFile(fs, f.Path)
+		if err != nil {
+			return err
+		}
+		for j := range f.Positions {
+			pos := &f.Positions[j]
+			token := secretToken(data[pos.Start:pos.End])
+			if token == "" {
+				continue
+			}
+			url, ok := searched[token]
+			if !ok {
+				if len(searched) == maxExposureSearches {
+					return nil
+				}
+				if url, err = searchPublicCode(ctx, client, repo, token); err != nil {
+					return err
+				}
+				searched[token] = url
+			}
+			if url != "" {
+				pos.Public = true
+				pos.PublicURL = url
+				pos.Severity = severityPublic
+			}
+		}
+	}
+	return nil
+}
+
+// secretToken returns the longest run of secret characters in match, the
+// part of a finding most likely to be the secret itself, or "" if there is
+// none long enough to search for.
+func secretToken(match []byte) (token string) {
+	for _, run := range secretTokenPattern.FindAll(match, -1) {
+		if len(run) > len(token) {
+			token = string(run)
+		}
+	}
+	return token
+}
+
+// searchPublicCode returns the URL of a file in a public repo other than repo
+// containing token, or "" if code search finds none. Only the first half of
+// token is searched for, so the secret itself is not disclosed to GitHub;
+// results are confirmed to hold all of token in their matched text.
+func searchPublicCode(ctx context.Context, client *github.Client, repo *github.Repository, token string) (string, error) {
+	result, _, err := client.Search.Code(ctx, strconv.Quote(exposurePrefix(token)), &github.SearchOptions{
+		TextMatch:   true,
+		ListOptions: github.ListOptions{PerPage: 20},
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, code := range result.CodeResults {
+		// Authenticated searches also cover private repos the token can read.
+		if code.Repository.GetPrivate() || code.Repository.GetFullName() == repo.GetFullName() {
+			continue
+		}
+		for _, tm := range code.TextMatches {
+			if strings.Contains(tm.GetFragment(), token) {
+				return code.GetHTMLURL(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// exposurePrefix returns the part of token searched for: its first half, or
+// minExposurePrefix characters if that is more.
+func exposurePrefix(token string) string {
+	n := len(token) / 2
+	if n < minExposurePrefix {
+		n = minExposurePrefix
+	}
+	if n > len(token) {
+		n = len(token)
+	}
+	return token[:n]
+}
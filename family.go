@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+)
+
+// forkFamilies groups repos, all owned by owner, into families of forks and
+// mirrors of the same codebase. A fork belongs to the family of its furthest
+// ancestor among repos, and mirrors of the same upstream, or of one of repos,
+// belong to one family. repos is returned reordered so each family follows
+// its canonical repo, the ancestor or the first mirror in repos' order, and
+// families keeps each repo's canonical repo name. Forks whose parents were
+// not enumerated, as with the REST API, are looked up one by one.
+func forkFamilies(ctx context.Context, client *github.Client, owner string, repos []*github.Repository) (ordered []*github.Repository, families map[string]string) {
+	byName := make(map[string]*github.Repository, len(repos))
+	byURL := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		byName[repo.GetName()] = repo
+		byURL[normalizeRepoURL(repo.GetHTMLURL())] = repo.GetName()
+	}
+
+	// Map each repo to its parent within repos, if any.
+	parents := make(map[string]string)
+	mirrorRoots := make(map[string]string)
+	for _, repo := range repos {
+		name := repo.GetName()
+		if mirror := repo.GetMirrorURL(); mirror != "" {
+			upstream := normalizeRepoURL(mirror)
+			if root, ok := byURL[upstream]; ok && root != name {
+				parents[name] = root
+			} else if root, ok := mirrorRoots[upstream]; ok {
+				parents[name] = root
+			} else {
+				mirrorRoots[upstream] = name
+			}
+			continue
+		}
+		if !repo.GetFork() {
+			continue
+		}
+		parent, err := repoParent(ctx, client, owner, repo)
+		if err != nil {
+			logrus.Errorf("forkFamilies: get %s: %v", name, err)
+			continue
+		}
+		if parent != nil && strings.EqualFold(parent.GetOwner().GetLogin(), owner) {
+			if _, ok := byName[parent.GetName()]; ok {
+				parents[name] = parent.GetName()
+			}
+		}
+	}
+
+	families = make(map[string]string, len(repos))
+	members := make(map[string][]*github.Repository)
+	for _, repo := range repos {
+		root := repo.GetName()
+		// Bound the walk in case of a cycle in the API's data.
+		for i := 0; i < len(repos); i++ {
+			parent, ok := parents[root]
+			if !ok {
+				break
+			}
+			root = parent
+		}
+		families[repo.GetName()] = root
+		if root != repo.GetName() {
+			members[root] = append(members[root], repo)
+		}
+	}
+
+	nFamilies := 0
+	for _, repo := range repos {
+		name := repo.GetName()
+		if families[name] != name {
+			continue
+		}
+		ordered = append(ordered, repo)
+		if len(members[name]) != 0 {
+			ordered = append(ordered, members[name]...)
+			nFamilies++
+		}
+	}
+	if nFamilies != 0 {
+		logrus.Infof("Found %d fork families; only files differing from each family's canonical repo will be checked.", nFamilies)
+	}
+	return ordered, families
+}
+
+// repoParent returns the repo that repo, a fork owned by owner, was forked
+// from, looking it up if it was not enumerated with repo.
+func repoParent(ctx context.Context, client *github.Client, owner string, repo *github.Repository) (*github.Repository, error) {
+	if repo.Parent != nil {
+		return repo.Parent, nil
+	}
+	full, _, err := client.Repositories.Get(ctx, owner, repo.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return full.GetParent(), nil
+}
+
+// normalizeRepoURL returns url without scheme, trailing ".git" or "/", or
+// case, so the HTML and clone URLs of a repo compare equal.
+func normalizeRepoURL(url string) string {
+	url = strings.ToLower(url)
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+len("://"):]
+	}
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, ".git")
+}
+
+// scanCache holds the positions found in files of a fork family's repos,
+// keyed by each file's path and content, so identical files in other repos
+// of the family are not checked again.
+type scanCache struct {
+	positions map[[sha256.Size]byte][]SensitivePos
+}
+
+func newScanCache() *scanCache {
+	return &scanCache{positions: make(map[[sha256.Size]byte][]SensitivePos)}
+}
+
+// scanFileData returns scanFileData(relPath, fileData), reusing the result
+// for an identical file at the same path if cache has one. A nil cache
+// always scans.
+func (cache *scanCache) scanFileData(relPath string, fileData []byte) []SensitivePos {
+	if cache == nil {
+		return scanFileData(relPath, fileData)
+	}
+	h := sha256.New()
+	h.Write([]byte(relPath))
+	h.Write([]byte{0})
+	h.Write(fileData)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+
+	positions, ok := cache.positions[key]
+	if !ok {
+		positions = scanFileData(relPath, fileData)
+		cache.positions[key] = positions
+	}
+	// Later stages modify positions in place, so each repo gets its own copy.
+	if positions == nil {
+		return nil
+	}
+	return append([]SensitivePos(nil), positions...)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// fileRule flags matches of Pattern in files whose repo-relative,
+// slash-separated path matches Path, or in every file if Path is nil. These
+// rules target files or formats that exist specifically to hold credentials,
+// where a generic rule would be too noisy to apply everywhere.
+//
+// If Keywords is set, the rule only applies to files containing one of them,
+// ignoring case. If MinEntropy is set, matches are only flagged if the
+// Shannon entropy of Pattern's first group, or the whole match if it has no
+// groups, is at least MinEntropy. Severity is set on the rule's findings.
+type fileRule struct {
+	Name        string
+	Path        *regexp.Regexp
+	Pattern     *regexp.Regexp
+	Remediation string
+	Keywords    []string
+	MinEntropy  float64
+	Severity    string
+}
+
+// credURLPattern matches a URL with a password in its userinfo.
+const credURLPattern = `[a-zA-Z][a-zA-Z0-9+.-]*://[^:\s/@]+:[^@\s]+@[^\s'"]+`
+
+var fileRules = []fileRule{
+	{
+		Name:        "git-credentials",
+		Path:        regexp.MustCompile(`(^|/)\.?git-credentials$`),
+		Pattern:     regexp.MustCompile(`(?m)^\s*` + credURLPattern),
+		Remediation: "Remove the file from the repo and its history, and revoke every credential it lists.",
+	},
+	{
+		Name: "gitconfig-credential",
+		Path: regexp.MustCompile(`(^|/)(\.gitconfig|[^/]*\.gitconfig|git/config)$`),
+		Pattern: regexp.MustCompile(`(?mi)^\s*(` +
+			`(password|token|oauth-?token)\s*=\s*\S.*` +
+			`|extraheader\s*=\s*authorization:.*` +
+			`|url\s*=\s*` + credURLPattern + `)$`),
+		Remediation: "Use a credential helper instead of storing credentials in git config, and revoke the committed credential.",
+	},
+	{
+		Name: "git-hook-credential",
+		Path: regexp.MustCompile(`(^|/)(\.githooks|\.husky|hooks)/[^/]+$`),
+		Pattern: regexp.MustCompile(`(?i)(password|passwd|token|secret|api[_-]?key)\s*[=:]\s*['"]?[^\s'"$]{8,}` +
+			`|` + credURLPattern),
+		Remediation: "Read the credential from the environment or a credential helper at hook run time, and revoke the committed credential.",
+	},
+	{
+		Name: "cloud-init-credential",
+		Path: regexp.MustCompile(`(^|/)[^/]*(cloud-init|cloud-config|cloudinit|user-data|userdata)[^/]*$`),
+		Pattern: regexp.MustCompile(`(?mi)^\s*-?\s*(password|passwd|plain_text_passwd|hashed_passwd)\s*:\s*\S+` +
+			`|^\s*-?\s*root:[^\s:]+` +
+			`|(kubeadm|swarm) join\b.*--token[= ]\S+` +
+			`|K3S_TOKEN=\S+`),
+		Remediation: "Pass passwords and join tokens to instances from a secrets manager or instance metadata at boot, rotate the committed values, and prefer SSH keys over passwords.",
+	},
+	{
+		Name: "packer-credential",
+		Path: regexp.MustCompile(`(^|/)([^/]*\.pkr\.(hcl|json)|[^/]*packer[^/]*\.json)$`),
+		// Values starting with '{' or '$' are variable references, not literals.
+		Pattern:     regexp.MustCompile(`(?i)"?(ssh_password|winrm_password|password|access_key|secret_key|client_secret|api_token|token)"?\s*[:=]\s*"[^"{$][^"]*"`),
+		Remediation: "Declare the value as a sensitive Packer variable supplied from the environment or a secrets manager, and rotate the committed value.",
+	},
+	{
+		Name:        "vagrantfile-credential",
+		Path:        regexp.MustCompile(`(^|/)Vagrantfile$`),
+		Pattern:     regexp.MustCompile(`(?i)\.(password|access_key_id|secret_access_key|token|api_key)\s*=\s*["'][^"']+["']`),
+		Remediation: "Read the value from ENV in the Vagrantfile instead of hardcoding it, and rotate the committed value.",
+	},
+	{
+		Name:        "docker-config-auth",
+		Path:        regexp.MustCompile(`(^|/)(\.docker/config\.json|\.dockercfg|[^/]*dockerconfig[^/]*\.json)$`),
+		Pattern:     regexp.MustCompile(`"(auth|identitytoken|registrytoken)"\s*:\s*"[A-Za-z0-9+/=._-]{8,}"`),
+		Remediation: "Remove the file from the repo and its history, revoke the registry token or change the password it encodes, and log in with a credential helper instead.",
+	},
+	{
+		Name: "registry-login-password",
+		// Passwords read from variables or stdin are not literals.
+		Pattern:     regexp.MustCompile(`\b(docker|podman|buildah|nerdctl|helm registry)\s+login\b[^\n&|;]*?\s(-p|--password)[ =]['"]?[^\s'"$-][^\s'"]*`),
+		Remediation: "Pipe the password to login --password-stdin from a secret store or the environment, and revoke the committed password.",
+	},
+	{
+		Name:        "android-signing-password",
+		Path:        regexp.MustCompile(`(^|/)[^/]*\.properties$`),
+		Pattern:     regexp.MustCompile(`(?mi)^\s*[\w.]*(store_?password|key_?password|store\.password|key\.password|storepass|keypass)\s*[=:]\s*[^\s$]+`),
+		Remediation: "Load keystore passwords from environment variables or a CI secret store, and rotate the signing key if the keystore itself is also exposed.",
+	},
+	{
+		Name: "gradle-signing-password",
+		Path: regexp.MustCompile(`(^|/)[^/]*\.gradle(\.kts)?$`),
+		// Only quoted literals; other values are usually lookups like
+		// System.getenv.
+		Pattern:     regexp.MustCompile(`(?i)(storePassword|keyPassword)\s*=?\s*["'][^"'$]+["']`),
+		Remediation: "Load keystore passwords from environment variables or a CI secret store, and rotate the signing key if the keystore itself is also exposed.",
+	},
+	{
+		Name:        "google-services-api-key",
+		Path:        regexp.MustCompile(`(^|/)(google-services\.json|GoogleService-Info\.plist)$`),
+		Pattern:     regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`),
+		Remediation: "Restrict the API key to your app's package name or bundle ID and the APIs it needs in the Google Cloud console; regenerate it if unrestricted.",
+	},
+	{
+		Name:        "plist-secret",
+		Path:        regexp.MustCompile(`(^|/)[^/]*\.plist$`),
+		Pattern:     regexp.MustCompile(`(?i)<key>[^<]*(api_?key|secret|token|password)[^<]*</key>\s*<string>[^<]{8,}</string>`),
+		Remediation: "Move the value out of the bundled plist, which ships inside every app binary, to a backend that issues scoped credentials, and rotate it.",
+	},
+	{
+		Name:        "playfab-secret-key",
+		Pattern:     regexp.MustCompile(`(?i)(DeveloperSecretKey|X-SecretKey|PLAYFAB_SECRET_KEY|PlayFabSecretKey)["']?\s*[:=]\s*["']?[A-Z0-9]{20,}`),
+		Remediation: "Title secret keys must only live on trusted servers; remove it from client code and assets and rotate it in Game Manager.",
+	},
+	{
+		Name:        "steam-api-key",
+		Pattern:     regexp.MustCompile(`(?i)steam[\w.-]*(web|api|publisher|partner)?[_-]?key["']?\s*[:=]\s*["']?[0-9A-F]{32}\b`),
+		Remediation: "Revoke the key in the Steamworks partner site and keep publisher Web API keys on backend servers only.",
+	},
+	{
+		Name:        "unity-license-file",
+		Path:        regexp.MustCompile(`\.ulf$`),
+		Pattern:     regexp.MustCompile(`<DeveloperData Value="[^"]+"`),
+		Remediation: "Remove the license file and store it as a CI secret; return and reactivate the seat if the repo was public.",
+	},
+	{
+		Name:        "unity-credential",
+		Pattern:     regexp.MustCompile(`(?i)UNITY_(PASSWORD|SERIAL|API_KEY|CLOUD_BUILD_API_KEY)["']?\s*[:=]\s*["']?[^\s"'$]{8,}`),
+		Remediation: "Store Unity account credentials and serials as CI secrets and change the account password or return the serial.",
+	},
+	{
+		Name:        "epic-online-services-secret",
+		Path:        regexp.MustCompile(`(^|/)Config/[^/]*\.ini$`),
+		Pattern:     regexp.MustCompile(`(?mi)^\s*ClientSecret\s*=\s*"?[^\s"]{16,}`),
+		Remediation: "Move the EOS client secret to server-side config or a build-time secret and rotate it in the Epic Developer Portal.",
+	},
+}
+
+// checkFileRules returns the positions of all fileRules and customRules
+// matches in fileData, given the file's repo-relative, slash-separated path.
+func checkFileRules(relPath string, fileData []byte) []SensitivePos {
+	positions := matchFileRules(fileRules, relPath, fileData)
+	return append(positions, matchFileRules(customRules, relPath, fileData)...)
+}
+
+// matchFileRules returns the positions of all matches of rules in fileData,
+// given the file's repo-relative, slash-separated path.
+func matchFileRules(rules []fileRule, relPath string, fileData []byte) (positions []SensitivePos) {
+	var lower []byte
+	for _, rule := range rules {
+		if rule.Path != nil && !rule.Path.MatchString(relPath) {
+			continue
+		}
+		if len(rule.Keywords) > 0 {
+			if lower == nil {
+				lower = bytes.ToLower(fileData)
+			}
+			if !containsKeyword(lower, rule.Keywords) {
+				continue
+			}
+		}
+		for _, loc := range rule.Pattern.FindAllSubmatchIndex(fileData, -1) {
+			if rule.MinEntropy > 0 {
+				value := fileData[loc[0]:loc[1]]
+				if len(loc) > 2 && loc[2] >= 0 {
+					value = fileData[loc[2]:loc[3]]
+				}
+				if detection.ShannonEntropy(value) < rule.MinEntropy {
+					continue
+				}
+			}
+			positions = append(positions, SensitivePos{
+				Start:       loc[0],
+				End:         loc[1],
+				Rule:        rule.Name,
+				Remediation: rule.Remediation,
+				Severity:    rule.Severity,
+			})
+		}
+	}
+	return positions
+}
+
+// containsKeyword reports whether the lowercased lowerData contains any of
+// keywords, ignoring case.
+func containsKeyword(lowerData []byte, keywords []string) bool {
+	for _, kw := range keywords {
+		if bytes.Contains(lowerData, []byte(strings.ToLower(kw))) {
+			return true
+		}
+	}
+	return false
+}
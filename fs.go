@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// walkFS calls fn for each regular file under dir in fs, in lexical order,
+// with the file's path relative to the root of fs. Directories that cannot
+// be read are passed to fn with an error.
+func walkFS(fs billy.Filesystem, dir string, fn func(relPath string, info os.FileInfo, err error)) {
+	infos, err := fs.ReadDir(dir)
+	if err != nil {
+		fn(dir, nil, err)
+		return
+	}
+	for _, info := range infos {
+		path := fs.Join(dir, info.Name())
+		switch {
+		case info.IsDir():
+			walkFS(fs, path, fn)
+		case info.Mode().IsRegular():
+			fn(path, info, nil)
+		}
+	}
+}
+
+// readFSFile reads the file at path in fs.
+func readFSFile(fs billy.Filesystem, path string) ([]byte, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
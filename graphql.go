@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// orgReposQuery fetches one page of an org's repos with every field the
+// crawler and its subcommands use, so enumeration costs one API call per 100
+// repos.
+const orgReposQuery = `query($org: String!, $privacy: RepositoryPrivacy, $cursor: String) {
+  organization(login: $org) {
+    repositories(first: 100, after: $cursor, privacy: $privacy) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        nameWithOwner
+        owner { login }
+        url
+        sshUrl
+        diskUsage
+        isPrivate
+        isFork
+        isTemplate
+        mirrorUrl
+        parent { name owner { login } defaultBranchRef { name } }
+        isArchived
+        pushedAt
+        primaryLanguage { name }
+        defaultBranchRef { name }
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLRepo struct {
+	Name            string
+	NameWithOwner   string
+	Owner           struct{ Login string }
+	URL             string
+	SSHURL          string `json:"sshUrl"`
+	DiskUsage       int
+	IsPrivate       bool
+	IsFork          bool
+	IsTemplate      bool
+	MirrorURL       string `json:"mirrorUrl"`
+	IsArchived      bool
+	PushedAt        *github.Timestamp
+	PrimaryLanguage *struct{ Name string }
+	DefaultBranch   *struct{ Name string } `json:"defaultBranchRef"`
+	Topics          struct {
+		Nodes []struct {
+			Topic struct{ Name string }
+		}
+	} `json:"repositoryTopics"`
+	Parent *struct {
+		Name          string
+		Owner         struct{ Login string }
+		DefaultBranch *struct{ Name string } `json:"defaultBranchRef"`
+	}
+}
+
+type graphQLOrgReposResponse struct {
+	Data struct {
+		Organization *struct {
+			Repositories struct {
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				}
+				Nodes []graphQLRepo
+			}
+		}
+	}
+	Errors []struct {
+		Message string
+	}
+}
+
+// listOrgReposGraphQL is the GraphQL equivalent of listOrgReposREST. The
+// GraphQL API requires an authenticated client.
+func listOrgReposGraphQL(ctx context.Context, client *github.Client, orgName, repoType string) ([]*github.Repository, error) {
+	vars := map[string]interface{}{"org": orgName}
+	switch repoType {
+	case "public", "private":
+		vars["privacy"] = strings.ToUpper(repoType)
+	}
+
+	var repos []*github.Repository
+	for {
+		req, err := client.NewRequest("POST", graphQLEndpoint(client), &graphQLRequest{
+			Query:     orgReposQuery,
+			Variables: vars,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var gr graphQLOrgReposResponse
+		if _, err := client.Do(ctx, req, &gr); err != nil {
+			return nil, err
+		}
+		if len(gr.Errors) != 0 {
+			return nil, errors.New(gr.Errors[0].Message)
+		}
+		if gr.Data.Organization == nil {
+			return nil, errors.New("organization not found: " + orgName)
+		}
+
+		page := gr.Data.Organization.Repositories
+		for _, node := range page.Nodes {
+			// go-github does not model templates, so they are recorded
+			// separately.
+			if node.IsTemplate {
+				templateRepos[node.URL] = true
+			}
+			repos = append(repos, node.toRepository())
+		}
+		if !page.PageInfo.HasNextPage {
+			return repos, nil
+		}
+		vars["cursor"] = page.PageInfo.EndCursor
+	}
+}
+
+// graphQLEndpoint returns the URL of the GraphQL API of client's host,
+// relative to its REST API's base URL. GitHub Enterprise Server serves its
+// REST API at /api/v3/ but its GraphQL API at /api/graphql.
+func graphQLEndpoint(client *github.Client) string {
+	u := *client.BaseURL
+	if !strings.HasSuffix(u.Path, "/api/v3/") {
+		return "graphql"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	return u.String()
+}
+
+// toRepository converts r into the REST representation used by the rest of
+// the crawler.
+func (r graphQLRepo) toRepository() *github.Repository {
+	repo := &github.Repository{
+		Name:     github.String(r.Name),
+		FullName: github.String(r.NameWithOwner),
+		Owner:    &github.User{Login: github.String(r.Owner.Login)},
+		HTMLURL:  github.String(r.URL),
+		CloneURL: github.String(r.URL + ".git"),
+		SSHURL:   github.String(r.SSHURL),
+		Size:     github.Int(r.DiskUsage),
+		Private:  github.Bool(r.IsPrivate),
+		Fork:     github.Bool(r.IsFork),
+		Archived: github.Bool(r.IsArchived),
+		PushedAt: r.PushedAt,
+		Language: new(string),
+		Topics:   []string{},
+	}
+	if r.PrimaryLanguage != nil {
+		repo.Language = github.String(r.PrimaryLanguage.Name)
+	}
+	if r.MirrorURL != "" {
+		repo.MirrorURL = github.String(r.MirrorURL)
+	}
+	if r.Parent != nil {
+		repo.Parent = &github.Repository{
+			Name:  github.String(r.Parent.Name),
+			Owner: &github.User{Login: github.String(r.Parent.Owner.Login)},
+		}
+		if r.Parent.DefaultBranch != nil {
+			repo.Parent.DefaultBranch = github.String(r.Parent.DefaultBranch.Name)
+		}
+	}
+	if r.DefaultBranch != nil {
+		repo.DefaultBranch = github.String(r.DefaultBranch.Name)
+	}
+	for _, n := range r.Topics.Nodes {
+		repo.Topics = append(repo.Topics, n.Topic.Name)
+	}
+	return repo
+}
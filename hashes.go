@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/estroz/seekret/detection"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Rules whose findings may be hashes rather than secrets.
+var hashRules = map[string]bool{
+	detection.HighEntropyBase64: true,
+	detection.HighEntropyHex:    true,
+}
+
+var (
+	// Digests named by their algorithm, as lockfiles' integrity fields, go.sum
+	// lines, and image references name them, ex. sha512-<base64>,
+	// h1:<base64>, or sha256:<hex>. The name either precedes a finding or
+	// starts it, as base64 runs include '-'.
+	digestBefore = regexp.MustCompile(`(?i)(sha(1|256|384|512)[-:]|h1:)$`)
+	digestStart  = regexp.MustCompile(`(?i)^sha(1|256|384|512)-`)
+	// Lines naming a hex string as a commit or digest, ex. rev: <sha> or
+	// checksum = <sha256>.
+	hashLine = regexp.MustCompile(`(?i)\b(commit|sha(1|256|512)?(sum)?|rev|revision|digest|checksum|hash)\b`)
+	hexHash  = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+)
+
+// dropHashes removes entropy findings of positions in fileData that are
+// hashes: git object IDs found in r's object database, if r is non-nil, hex
+// strings pinned with @ or on a line naming a commit or digest, and digests
+// prefixed with their algorithm. No findings are removed if default ignores
+// are disabled.
+func dropHashes(r *git.Repository, fileData []byte, positions []SensitivePos) []SensitivePos {
+	if noDefaultIgnores {
+		return positions
+	}
+	kept := positions[:0]
+	for _, pos := range positions {
+		if !hashRules[pos.Rule] || !isHash(r, fileData, pos) {
+			kept = append(kept, pos)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// isHash reports whether the finding pos in fileData is a hash, as
+// dropHashes judges.
+func isHash(r *git.Repository, fileData []byte, pos SensitivePos) bool {
+	if pos.End > len(fileData) {
+		return false
+	}
+	match := fileData[pos.Start:pos.End]
+	before := fileData[:pos.Start]
+	if len(before) > len("sha512-") {
+		before = before[len(before)-len("sha512-"):]
+	}
+	if digestBefore.Match(before) || digestStart.Match(match) {
+		return true
+	}
+	if !hexHash.Match(match) {
+		return false
+	}
+	// Commits, trees, and blobs of the repo itself, ex. in changelogs.
+	if len(match) == 40 && r != nil && r.Storer.HasEncodedObject(plumbing.NewHash(string(bytes.ToLower(match)))) == nil {
+		return true
+	}
+	// Pinned dependencies, ex. uses: actions/checkout@<sha>.
+	if pos.Start > 0 && fileData[pos.Start-1] == '@' {
+		return true
+	}
+	start, end := lineBounds(fileData, pos.Start, pos.End, 0)
+	return hashLine.Match(fileData[start:end])
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/estroz/seekret/scanerr"
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Public orgs and users watched for the company's token formats.
+	honeypotTargets []string
+	// Time between passes over honeypotTargets' repos. Zero makes one pass.
+	honeypotInterval time.Duration
+)
+
+var honeypotCmd = &cobra.Command{
+	Use:   "honeypot",
+	Short: "Watch outside orgs and users for the company's internal token formats",
+	Long: `Watch outside orgs and users for the company's internal token formats.
+
+Each --target, a public org or user such as a typosquat or lookalike of the
+company's org, is checked every --interval for content matching the rules of
+--rules, which should describe the company's internal token formats. Only a
+target's repos created or pushed to since the last pass are checked, so
+passes stay light. Each finding of those rules is logged as an alert and
+written as a JSON line to stdout as soon as its repo is checked, once; other
+rules' findings are not reported. Targets' .credignore and .seekret.yaml
+files are not honored, since whoever controls a target could use them to
+hide a leak.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(honeypotTargets) == 0 {
+			logrus.Error("honeypot: at least one --target is required")
+			os.Exit(1)
+		}
+		if rulesFile == "" {
+			logrus.Error("honeypot: --rules describing the token formats to watch for is required")
+			os.Exit(1)
+		}
+		var err error
+		if customRules, err = loadRulesFile(rulesFile); err != nil {
+			logrus.Error("honeypot: load rules: ", err)
+			os.Exit(1)
+		}
+		// Targets may be controlled by whoever leaked into them, so their
+		// own ignores and config are not trusted.
+		ignoreRepoSuppressions = true
+
+		ctx := context.Background()
+		client := newClient(ctx)
+		rw, err := newJSONLinesWriter("")
+		if err != nil {
+			logrus.Error("honeypot: ", err)
+			os.Exit(1)
+		}
+		hp := newHoneypot(&findingLinesWriter{jsonLinesWriter: rw})
+		for {
+			for _, target := range honeypotTargets {
+				if err := hp.check(ctx, client, target); err != nil {
+					logrus.Errorf("honeypot: %s: %v", target, err)
+				}
+			}
+			if honeypotInterval <= 0 {
+				return
+			}
+			time.Sleep(honeypotInterval)
+		}
+	},
+}
+
+func init() {
+	honeypotCmd.Flags().StringSliceVar(&honeypotTargets, "target", nil, "Public org or user to watch. Repeat for each target.")
+	honeypotCmd.Flags().StringVar(&rulesFile, "rules", "", "YAML file of rules, as skrt --rules reads, describing the token formats to watch for.")
+	honeypotCmd.Flags().DurationVar(&honeypotInterval, "interval", 10*time.Minute, "Time between passes over the targets' repos. 0 makes one pass.")
+}
+
+// honeypot alerts on findings of custom rules in repos of outside owners,
+// remembering what it has checked and reported between passes.
+type honeypot struct {
+	rw ResultWriter
+	// Full names of repos checked, and when they were last pushed to when
+	// they were.
+	pushed map[string]time.Time
+	// Full names of repos of the current pass and when they were last
+	// pushed to, recorded in pushed once each is checked without errors.
+	queued map[string]time.Time
+	// IDs of findings reported.
+	reported map[string]bool
+	// Owner of the repos being checked.
+	owner string
+	// Errors of the current pass over owner's repos.
+	errs errorSummary
+}
+
+func newHoneypot(rw ResultWriter) *honeypot {
+	return &honeypot{rw: rw, pushed: make(map[string]time.Time), reported: make(map[string]bool)}
+}
+
+// check checks the public repos of owner, an org or user, that are new or
+// were pushed to since they were last checked.
+func (hp *honeypot) check(ctx context.Context, client *github.Client, owner string) error {
+	repos, err := listOwnerRepos(ctx, client, owner)
+	if err != nil {
+		return &scanerr.APIError{Op: "list repos", Err: err, Retriable: scanerr.Transient(err)}
+	}
+	var changed []*github.Repository
+	hp.queued = make(map[string]time.Time)
+	for _, repo := range repos {
+		fullName := strings.ToLower(repo.GetFullName())
+		if last, ok := hp.pushed[fullName]; ok && !repo.GetPushedAt().After(last) {
+			continue
+		}
+		hp.queued[fullName] = repo.GetPushedAt().Time
+		changed = append(changed, repo)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	logrus.Infof("honeypot: checking %d new or updated repos of %s", len(changed), owner)
+	hp.owner = owner
+	hp.errs = errorSummary{}
+	defer hp.errs.log("honeypot: " + owner + ": ")
+	return CrawlRepos(ctx, client, owner, changed, hp)
+}
+
+// WriteRepo alerts on and writes sr's findings of custom rules not already
+// reported. sr is named by its full name.
+func (hp *honeypot) WriteRepo(sr SensitiveRepo) error {
+	sr.Name = hp.fullName(sr.Name)
+	hp.errs.WriteRepo(sr)
+	custom := make(map[string]bool, len(customRules))
+	for _, r := range customRules {
+		custom[r.Name] = true
+	}
+
+	alert := SensitiveRepo{Name: sr.Name}
+	err := sr.eachFile(func(file SensitiveFile) error {
+		var positions []SensitivePos
+		for _, pos := range file.Positions {
+			id := findingID(sr.Name, file.Path, file.Commit, pos.Rule, pos.Start)
+			if !custom[pos.Rule] || hp.reported[id] {
+				continue
+			}
+			hp.reported[id] = true
+			logrus.Warnf("HONEYPOT MATCH: repo %s file %s rule %s", sr.Name, file.Path, pos.Rule)
+			positions = append(positions, pos)
+		}
+		if positions != nil {
+			file.Positions = positions
+			alert.Files = append(alert.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if alert.Files != nil {
+		if err := hp.rw.WriteRepo(alert); err != nil {
+			return err
+		}
+	}
+	hp.markChecked(sr)
+	return nil
+}
+
+// WriteCleanRepo records that sr, which has no findings, was checked.
+func (hp *honeypot) WriteCleanRepo(sr SensitiveRepo) error {
+	sr.Name = hp.fullName(sr.Name)
+	hp.markChecked(sr)
+	return nil
+}
+
+// markChecked records when sr, named by its full name, was last pushed to if
+// it was checked without errors, so it is not checked again until it is next
+// pushed to. Repos that could not be fully checked are retried next pass.
+func (hp *honeypot) markChecked(sr SensitiveRepo) {
+	if len(sr.Errors) != 0 {
+		return
+	}
+	fullName := strings.ToLower(sr.Name)
+	if pushedAt, ok := hp.queued[fullName]; ok {
+		hp.pushed[fullName] = pushedAt
+	}
+}
+
+// fullName returns name, a repo's name as CrawlRepos reports it, as a full
+// name.
+func (hp *honeypot) fullName(name string) string {
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return hp.owner + "/" + name
+}
+
+// listOwnerRepos requests every page of public repos owned by owner, an org
+// or user.
+func listOwnerRepos(ctx context.Context, client *github.Client, owner string) ([]*github.Repository, error) {
+	opt := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	var repos []*github.Repository
+	for {
+		page, resp, err := client.Repositories.List(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
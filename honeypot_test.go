@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+)
+
+// recordingWriter is a ResultWriter keeping the repos written to it.
+type recordingWriter struct {
+	repos []SensitiveRepo
+}
+
+func (w *recordingWriter) WriteRepo(sr SensitiveRepo) error {
+	w.repos = append(w.repos, sr)
+	return nil
+}
+
+func TestHoneypotIgnoresTargetSuppressions(t *testing.T) {
+	rule, err := ruleSpec{Name: "acme-token", Pattern: `acme_[A-Za-z0-9]{32}`}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(rules []fileRule) { customRules = rules }(customRules)
+	customRules = []fileRule{rule}
+	defer func() { ignoreRepoSuppressions = false }()
+	ignoreRepoSuppressions = true
+
+	// The target tries to hide its leak from scans with its .credignore.
+	fs := memfs.New()
+	files := map[string]string{
+		"leak.env":     "ACME_TOKEN=acme_Q3ZK7WLMN4PX2RBTq8Zt3XmLw9Rk2VbN\n",
+		credIgnoreFile: "leak.env\n",
+	}
+	for name, content := range files {
+		if err := util.WriteFile(fs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sr := SensitiveRepo{Name: "leaky"}
+	scanFS(context.Background(), &sr, fs, nil, "", nil, nil)
+
+	rw := &recordingWriter{}
+	hp := newHoneypot(rw)
+	hp.owner = "lookalike-org"
+	if err := hp.WriteRepo(sr); err != nil {
+		t.Fatal(err)
+	}
+	if len(rw.repos) != 1 || len(rw.repos[0].Files) != 1 {
+		t.Fatalf("got alerts %+v, want one for leak.env", rw.repos)
+	}
+	file := rw.repos[0].Files[0]
+	if file.Path != "leak.env" || len(file.Positions) != 1 || file.Positions[0].Rule != "acme-token" {
+		t.Errorf("got alert for %s %+v, want acme-token in leak.env", file.Path, file.Positions)
+	}
+}
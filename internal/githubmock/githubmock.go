@@ -0,0 +1,305 @@
+// Package githubmock serves a fake GitHub REST API over HTTP from fixture
+// repos, so crawls can be run end to end, including pagination and rate
+// limiting, without contacting GitHub.
+package githubmock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Server is a mock GitHub API for one org. Set its fields before making
+// requests.
+type Server struct {
+	*httptest.Server
+
+	// Org owns Repos.
+	Org   string
+	Repos []*github.Repository
+	// Files maps "owner/repo/path" to the contents served for that file.
+	// Other files are not found.
+	Files map[string]string
+	// Comparisons maps "owner/repo/base...head" to the comparison served for
+	// it. Other comparisons are not found.
+	Comparisons map[string]*github.CommitsComparison
+	// PerPage is the largest page size of repo lists, whatever size clients
+	// request. Lower it to paginate a few fixture repos.
+	PerPage int
+	// Scopes is sent as the X-OAuth-Scopes header of /user responses.
+	Scopes string
+	// RateLimit is the number of requests allowed per RateLimitWindow.
+	// Zero means unlimited. Requests over the limit are refused with 403, as
+	// GitHub does.
+	RateLimit       int
+	RateLimitWindow time.Duration
+
+	mu          sync.Mutex
+	requests    []string
+	used        int
+	windowStart time.Time
+}
+
+// NewServer starts a Server for org serving repos. Close it when done.
+func NewServer(org string, repos []*github.Repository) *Server {
+	s := &Server{
+		Org:             org,
+		Repos:           repos,
+		Files:           make(map[string]string),
+		Comparisons:     make(map[string]*github.CommitsComparison),
+		PerPage:         100,
+		RateLimitWindow: time.Hour,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// GitHubClient returns a client of s's API, authenticated with token unless
+// it is empty.
+func (s *Server) GitHubClient(token string) *github.Client {
+	var hc *http.Client
+	if token != "" {
+		hc = &http.Client{Transport: tokenTransport(token)}
+	}
+	client := github.NewClient(hc)
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+	return client
+}
+
+// Requests returns the method and path of each request served, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// LoadRepos reads a JSON array of repos in the GitHub API's format.
+func LoadRepos(path string) ([]*github.Repository, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var repos []*github.Repository
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", path, err)
+	}
+	return repos, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allow(w, r) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"message": "API rate limit exceeded"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/user":
+		if s.Scopes != "" {
+			w.Header().Set("X-OAuth-Scopes", s.Scopes)
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"login": "mock"})
+	case r.Method == "GET" && r.URL.Path == "/rate_limit":
+		s.serveRateLimit(w)
+	case r.Method == "GET" && len(parts) == 3 && (parts[0] == "orgs" || parts[0] == "users") && parts[2] == "repos":
+		s.serveRepoList(w, r, parts[1])
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "repos":
+		if repo := s.repo(parts[1], parts[2]); repo != nil {
+			writeJSON(w, http.StatusOK, repo)
+			return
+		}
+		writeNotFound(w)
+	case r.Method == "GET" && len(parts) > 4 && parts[0] == "repos" && parts[3] == "contents":
+		s.serveContents(w, parts[1], parts[2], strings.Join(parts[4:], "/"))
+	case r.Method == "GET" && len(parts) == 5 && parts[0] == "repos" && parts[3] == "compare":
+		if cmp, ok := s.Comparisons[parts[1]+"/"+parts[2]+"/"+parts[4]]; ok {
+			writeJSON(w, http.StatusOK, cmp)
+			return
+		}
+		writeNotFound(w)
+	case r.Method == "GET" && r.URL.Path == "/search/code":
+		s.serveCodeSearch(w, r.URL.Query().Get("q"))
+	default:
+		writeNotFound(w)
+	}
+}
+
+// allow counts r against the rate limit, sets rate limit headers on w, and
+// reports whether r is within the limit.
+func (s *Server) allow(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	if s.RateLimit == 0 {
+		return true
+	}
+
+	now := time.Now()
+	if s.windowStart.IsZero() || now.Sub(s.windowStart) >= s.RateLimitWindow {
+		s.windowStart, s.used = now, 0
+	}
+	ok := s.used < s.RateLimit
+	if ok {
+		s.used++
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.RateLimit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.RateLimit-s.used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.windowStart.Add(s.RateLimitWindow).Unix(), 10))
+	return ok
+}
+
+func (s *Server) serveRateLimit(w http.ResponseWriter) {
+	s.mu.Lock()
+	limit, remaining := s.RateLimit, s.RateLimit-s.used
+	reset := s.windowStart.Add(s.RateLimitWindow).Unix()
+	s.mu.Unlock()
+	if limit == 0 {
+		limit, remaining, reset = 5000, 5000, time.Now().Add(time.Hour).Unix()
+	}
+	rate := map[string]int64{"limit": int64(limit), "remaining": int64(remaining), "reset": reset}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"resources": map[string]interface{}{"core": rate}})
+}
+
+// serveRepoList serves a page of the org's repos, listed as an org's or a
+// user's, with Link headers to the next and last pages as GitHub sends.
+func (s *Server) serveRepoList(w http.ResponseWriter, r *http.Request, org string) {
+	if org != s.Org {
+		writeNotFound(w)
+		return
+	}
+	q := r.URL.Query()
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if perPage <= 0 || perPage > s.PerPage {
+		perPage = s.PerPage
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * perPage
+	if start > len(s.Repos) {
+		start = len(s.Repos)
+	}
+	end := start + perPage
+	if end > len(s.Repos) {
+		end = len(s.Repos)
+	}
+	lastPage := (len(s.Repos) + perPage - 1) / perPage
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	link := func(p int, rel string) string {
+		q.Set("page", strconv.Itoa(p))
+		q.Set("per_page", strconv.Itoa(perPage))
+		return fmt.Sprintf(`<%s%s?%s>; rel="%s"`, s.URL, r.URL.Path, q.Encode(), rel)
+	}
+	var links []string
+	if page < lastPage {
+		links = append(links, link(page+1, "next"), link(lastPage, "last"))
+	}
+	if page > 1 {
+		links = append(links, link(1, "first"), link(page-1, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	writeJSON(w, http.StatusOK, s.Repos[start:end])
+}
+
+func (s *Server) serveContents(w http.ResponseWriter, owner, name, path string) {
+	content, ok := s.Files[owner+"/"+name+"/"+path]
+	if !ok || s.repo(owner, name) == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, &github.RepositoryContent{
+		Type:     github.String("file"),
+		Name:     github.String(path[strings.LastIndex(path, "/")+1:]),
+		Path:     github.String(path),
+		Encoding: github.String("base64"),
+		Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+	})
+}
+
+// serveCodeSearch serves the Files of s's repos containing the search terms
+// in q, quoted or not, with the line of the first match as a text match.
+// Qualifiers such as "org:" are not supported.
+func (s *Server) serveCodeSearch(w http.ResponseWriter, q string) {
+	term := q
+	if unquoted, err := strconv.Unquote(q); err == nil {
+		term = unquoted
+	}
+	result := &github.CodeSearchResult{CodeResults: []github.CodeResult{}}
+	for key, content := range s.Files {
+		parts := strings.SplitN(key, "/", 3)
+		repo := s.repo(parts[0], parts[1])
+		i := strings.Index(content, term)
+		if repo == nil || i < 0 {
+			continue
+		}
+		start := strings.LastIndex(content[:i], "\n") + 1
+		end := len(content)
+		if n := strings.IndexByte(content[i:], '\n'); n >= 0 {
+			end = i + n
+		}
+		result.CodeResults = append(result.CodeResults, github.CodeResult{
+			Name:        github.String(parts[2][strings.LastIndex(parts[2], "/")+1:]),
+			Path:        github.String(parts[2]),
+			HTMLURL:     github.String(fmt.Sprintf("https://github.com/%s/blob/%s/%s", repo.GetFullName(), repo.GetDefaultBranch(), parts[2])),
+			Repository:  repo,
+			TextMatches: []github.TextMatch{{Fragment: github.String(content[start:end])}},
+		})
+	}
+	result.Total = github.Int(len(result.CodeResults))
+	writeJSON(w, http.StatusOK, result)
+}
+
+// repo returns the repo owner/name, or nil if s has none.
+func (s *Server) repo(owner, name string) *github.Repository {
+	if owner != s.Org {
+		return nil
+	}
+	for _, repo := range s.Repos {
+		if repo.GetName() == name {
+			return repo
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+// tokenTransport authenticates requests with a token, as an OAuth client
+// would.
+type tokenTransport string
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "token "+string(t))
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Also list private repos, which requires the repo scope.
+	inventoryPrivate bool
+	// Store whose scans and findings fill the last scan column.
+	inventoryStoreSpec string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "List an organization's repos and their scan-relevant metadata",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newClient(ctx)
+
+		// Tokens without the repo scope list only public repos.
+		repoType, scopes := "public", []string{"read:org"}
+		if inventoryPrivate {
+			repoType, scopes = "all", []string{"repo", "read:org"}
+		}
+		if err := preflight(ctx, client, orgName, scopes...); err != nil {
+			logrus.Error("inventory: ", err)
+			os.Exit(1)
+		}
+
+		var store Store
+		if inventoryStoreSpec != "" {
+			var err error
+			if store, err = openStore(inventoryStoreSpec); err != nil {
+				logrus.Error("inventory: open store: ", err)
+				os.Exit(1)
+			}
+			defer store.Close()
+		}
+		if err := Inventory(ctx, client, orgName, repoType, store, os.Stdout); err != nil {
+			logrus.Error("inventory: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	inventoryCmd.Flags().BoolVar(&inventoryPrivate, "private", false, "Also list private repos. Requires --oauth-token with the repo scope.")
+	inventoryCmd.Flags().StringVar(&inventoryStoreSpec, "store", "", "Store scans were saved to, as with the root command's --store, from which each repo's last scan status is read.")
+}
+
+// Inventory lists repos of type repoType, as the REST API's type parameter,
+// visible to client in orgName, with size, language, last push time,
+// visibility, and whether a top-level .credignore file exists, and writes
+// them to w as a table. If store is not nil, each repo's last scan status is
+// also listed: when the org was last scanned and how many findings that scan
+// left open, or "never" for repos created since.
+func Inventory(ctx context.Context, client *github.Client, orgName, repoType string, store Store, w io.Writer) error {
+	repos, err := listOrgRepos(ctx, client, orgName, repoType)
+	if err != nil {
+		return err
+	}
+
+	var lastScan func(*github.Repository) string
+	header := "NAME\tSIZE(KB)\tLANGUAGE\tLAST PUSH\tVISIBILITY\tCREDIGNORE"
+	if store != nil {
+		if lastScan, err = lastScanStatus(store, orgName); err != nil {
+			return err
+		}
+		header += "\tLAST SCAN"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	for _, repo := range repos {
+		if repo.Name == nil || *repo.Name == "" {
+			continue
+		}
+		repoName := *repo.Name
+
+		// A missing .credignore is reported as a 404; any other error leaves
+		// the column unknown.
+		credIgnore := "unknown"
+		_, _, resp, err := client.Repositories.GetContents(ctx, orgName, repoName, credIgnoreFile, nil)
+		switch {
+		case err == nil:
+			credIgnore = "yes"
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			credIgnore = "no"
+		default:
+			logrus.Warnf("Inventory: GetContents %s: %v", repoName, err)
+		}
+
+		visibility := "public"
+		if repo.GetPrivate() {
+			visibility = "private"
+		}
+		pushedAt := "never"
+		if repo.PushedAt != nil {
+			pushedAt = repo.GetPushedAt().Format("2006-01-02")
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s",
+			repoName, repo.GetSize(), repo.GetLanguage(), pushedAt, visibility, credIgnore)
+		if lastScan != nil {
+			fmt.Fprintf(tw, "\t%s", lastScan(repo))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// lastScanStatus returns a function describing a repo of orgName as of the
+// org's latest scan saved to store: the scan's date and the number of the
+// repo's findings it saw that are still open, or "clean" if none are. Repos
+// are "never" scanned if the org never was or they were created after its
+// latest scan started.
+func lastScanStatus(store Store, orgName string) (func(*github.Repository) string, error) {
+	scans, err := store.QueryScans(orgName)
+	if err != nil {
+		return nil, err
+	}
+	if len(scans) == 0 {
+		return func(*github.Repository) string { return "never" }, nil
+	}
+	latest := scans[len(scans)-1]
+
+	findings, err := store.QueryFindings(FindingQuery{Status: statusOpen})
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]int)
+	for _, f := range findings {
+		// Findings the latest scan did not see have since been fixed.
+		if !f.LastSeen.Before(latest.StartedAt) {
+			open[strings.ToLower(f.Repo)]++
+		}
+	}
+
+	date := latest.StartedAt.Format("2006-01-02")
+	return func(repo *github.Repository) string {
+		if repo.CreatedAt != nil && repo.GetCreatedAt().Time.After(latest.StartedAt) {
+			return "never"
+		}
+		if n := open[strings.ToLower(orgName+"/"+repo.GetName())]; n > 0 {
+			return fmt.Sprintf("%s %d open", date, n)
+		}
+		return date + " clean"
+	}, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// k8sSecret is a Kubernetes Secret manifest: its name, and its data values,
+// base64-encoded, and stringData values, in plain text. Values' Keys are
+// their data keys.
+type k8sSecret struct {
+	Name       string
+	Data       []structValue
+	StringData []structValue
+}
+
+// k8sSecrets returns the Secret manifests among values, those of a JSON or
+// YAML file, including Secrets in the items of a List.
+func k8sSecrets(values []structValue) (secrets []k8sSecret) {
+	for _, kind := range values {
+		if kind.Key != "kind" || kind.Value != "Secret" || !strings.HasSuffix(kind.Path, "kind") {
+			continue
+		}
+		prefix := strings.TrimSuffix(kind.Path, "kind")
+		var s k8sSecret
+		for _, v := range values {
+			if v.Doc != kind.Doc {
+				continue
+			}
+			switch v.Path {
+			case prefix + "metadata.name":
+				s.Name = v.Value
+			case prefix + "data." + v.Key:
+				s.Data = append(s.Data, v)
+			case prefix + "stringData." + v.Key:
+				s.StringData = append(s.StringData, v)
+			}
+		}
+		secrets = append(secrets, s)
+	}
+	return secrets
+}
+
+// findK8sSecrets decodes the data values of secrets and returns the positions
+// of rule matches in the decoded text not already in positions. Each data key
+// is matched as the name of a file, as a Secret's keys are when it is
+// mounted, so file rules apply too. Positions cover the encoded value.
+func findK8sSecrets(secrets []k8sSecret, positions []SensitivePos) (found []SensitivePos) {
+	for _, s := range secrets {
+		for _, v := range s.Data {
+			// Long values may be wrapped across lines.
+			decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(v.Value), ""))
+			if err != nil || len(decoded) == 0 || !isText(decoded) {
+				continue
+			}
+			for _, pos := range matchRules(v.Key, decoded) {
+				pos.Start, pos.End = v.Start, v.End
+				pos.Obfuscation = "base64"
+				if overlapsRule(positions, pos) || overlapsRule(found, pos) {
+					continue
+				}
+				found = append(found, pos)
+			}
+		}
+	}
+	return found
+}
+
+// labelK8sSecrets adds the name of the Secret among secrets and the data key
+// each of positions is found in, if any, to its Details as secret and
+// data_key.
+func labelK8sSecrets(secrets []k8sSecret, positions []SensitivePos) {
+	for _, s := range secrets {
+		for _, values := range [][]structValue{s.Data, s.StringData} {
+			for _, v := range values {
+				for i := range positions {
+					pos := &positions[i]
+					if pos.Start < v.Start || pos.Start >= v.End {
+						continue
+					}
+					if pos.Details == nil {
+						pos.Details = map[string]string{}
+					}
+					pos.Details["secret"] = s.Name
+					pos.Details["data_key"] = v.Key
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+)
+
+const (
+	// Size in bytes of each window of a large file scanned at once.
+	largeFileChunkSize = 8 << 20
+	// Bytes shared by adjacent windows, so data spanning a window boundary is
+	// still seen whole by one window. Must exceed the longest expected match.
+	largeFileChunkOverlap = 4 << 10
+)
+
+// scanLargeFile checks a file too large to copy onto the heap for sensitive
+// data. The file is memory-mapped where supported and each of its overlapping
+// windows passed to scan, which returns positions relative to the window.
+func scanLargeFile(path string, size int64, scan func(window []byte) []SensitivePos) ([]SensitivePos, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return scanFileChunks(f, size, scan)
+}
+
+// scanChunked runs scan over data in overlapping windows and returns
+// positions relative to the start of data. Each window keeps only matches
+// starting in its first largeFileChunkSize bytes, which no other window
+// starts in, so matches in overlaps are not duplicated.
+func scanChunked(data []byte, scan func(window []byte) []SensitivePos) (positions []SensitivePos) {
+	for off := 0; off < len(data); off += largeFileChunkSize {
+		end := off + largeFileChunkSize + largeFileChunkOverlap
+		if end > len(data) {
+			end = len(data)
+		}
+		for _, pos := range scan(data[off:end]) {
+			if pos.Start >= largeFileChunkSize {
+				continue
+			}
+			pos.Start += off
+			pos.End += off
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
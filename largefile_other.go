@@ -0,0 +1,31 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// scanFileChunks reads f one window at a time into a reused buffer and scans
+// each with scan, on platforms where mmap is unavailable.
+func scanFileChunks(f *os.File, size int64, scan func(window []byte) []SensitivePos) (positions []SensitivePos, err error) {
+	buf := make([]byte, largeFileChunkSize+largeFileChunkOverlap)
+	for off := int64(0); off < size; off += largeFileChunkSize {
+		n, err := f.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		for _, pos := range scan(buf[:n]) {
+			// Matches past the chunk are the next window's.
+			if pos.Start >= largeFileChunkSize {
+				continue
+			}
+			pos.Start += int(off)
+			pos.End += int(off)
+			positions = append(positions, pos)
+		}
+	}
+	return positions, nil
+}
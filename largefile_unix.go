@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// scanFileChunks maps f into memory and scans it in windows with scan. Mapped pages are
+// backed by the page cache, so RSS stays proportional to the window size
+// rather than the file size.
+func scanFileChunks(f *os.File, size int64, scan func(window []byte) []SensitivePos) ([]SensitivePos, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Munmap(data)
+
+	return scanChunked(data, scan), nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// Objects requested per LFS batch API call.
+const lfsBatchSize = 100
+
+// Media type of the LFS batch API.
+const lfsMediaType = "application/vnd.git-lfs+json"
+
+var (
+	lfsOIDPattern  = regexp.MustCompile(`(?m)^oid sha256:([0-9a-f]{64})$`)
+	lfsSizePattern = regexp.MustCompile(`(?m)^size (\d+)$`)
+)
+
+// lfsPointer is a Git LFS pointer file at Path, standing in for the object
+// with SHA-256 OID of Size bytes.
+type lfsPointer struct {
+	Path string `json:"-"`
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// parseLFSPointer returns the object fileData, a pointer file, points to.
+func parseLFSPointer(fileData []byte) (p lfsPointer, ok bool) {
+	if !isLFSPointer(fileData) {
+		return p, false
+	}
+	oid := lfsOIDPattern.FindSubmatch(fileData)
+	size := lfsSizePattern.FindSubmatch(fileData)
+	if oid == nil || size == nil {
+		return p, false
+	}
+	n, err := strconv.ParseInt(string(size[1]), 10, 64)
+	if err != nil {
+		return p, false
+	}
+	return lfsPointer{OID: string(oid[1]), Size: n}, true
+}
+
+// fetchLFSObjects replaces Git LFS pointer files in fs, a checkout of repo,
+// with the objects they point to, as git lfs pull does, so the objects are
+// checked like any other file. Objects larger than lfsMaxObjectSize, and
+// those that would bring the total fetched past lfsBudget, are left as
+// pointers. Failures are added to sr.
+func fetchLFSObjects(ctx context.Context, sr *SensitiveRepo, repo *github.Repository, fs billy.Filesystem) {
+	var (
+		pointers []lfsPointer
+		total    int64
+		skipped  int
+	)
+	walkFS(fs, "", func(relPath string, info os.FileInfo, err error) {
+		if err != nil || info.Size() >= maxLFSPointerSize || strings.HasPrefix(filepath.ToSlash(relPath), ".git/") {
+			return
+		}
+		data, err := readFSFile(fs, relPath)
+		if err != nil {
+			return
+		}
+		p, ok := parseLFSPointer(data)
+		if !ok {
+			return
+		}
+		if p.Size > lfsMaxObjectSize || total+p.Size > lfsBudget {
+			skipped++
+			return
+		}
+		p.Path = relPath
+		pointers = append(pointers, p)
+		total += p.Size
+	})
+	if skipped != 0 {
+		logrus.Infof("%s: %d Git LFS objects exceed --lfs-max-size or --lfs-budget and will not be fetched.", sr.Name, skipped)
+	}
+
+	for len(pointers) != 0 {
+		batch := pointers
+		if len(batch) > lfsBatchSize {
+			batch = batch[:lfsBatchSize]
+		}
+		pointers = pointers[len(batch):]
+
+		actions, err := lfsBatch(ctx, repo, batch)
+		if err != nil {
+			sr.addError("lfs", "", err)
+			return
+		}
+		for _, p := range batch {
+			a, ok := actions[p.OID]
+			if !ok {
+				sr.addError("lfs", p.Path, fmt.Errorf("object %s not offered by server", p.OID))
+				continue
+			}
+			if err := downloadLFSObject(ctx, fs, p, a); err != nil {
+				sr.addError("lfs", p.Path, err)
+			}
+		}
+	}
+}
+
+// lfsAction is how to download an LFS object, from the batch API.
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+// lfsBatch asks repo's LFS server how to download the objects of pointers,
+// and returns the download actions by OID. Objects the server has no action
+// for, ex. because they are missing, are left out.
+func lfsBatch(ctx context.Context, repo *github.Repository, pointers []lfsPointer) (map[string]lfsAction, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"operation": "download",
+		"transfers": []string{"basic"},
+		"objects":   pointers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(repo.GetCloneURL(), ".git") + ".git/info/lfs/objects/batch"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if accessToken != "" {
+		req.SetBasicAuth("x-access-token", accessToken)
+	}
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lfs batch: %s", resp.Status)
+	}
+	var result struct {
+		Objects []struct {
+			OID     string `json:"oid"`
+			Actions struct {
+				Download *lfsAction `json:"download"`
+			} `json:"actions"`
+		} `json:"objects"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	actions := make(map[string]lfsAction, len(result.Objects))
+	for _, o := range result.Objects {
+		if o.Actions.Download != nil {
+			actions[o.OID] = *o.Actions.Download
+		}
+	}
+	return actions, nil
+}
+
+// downloadLFSObject downloads the object of p with a and writes it over p's
+// pointer file in fs, if its size and hash match the pointer's.
+func downloadLFSObject(ctx context.Context, fs billy.Filesystem, p lfsPointer, a lfsAction) error {
+	req, err := http.NewRequest("GET", a.Href, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range a.Header {
+		req.Header.Set(k, v)
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download lfs object: %s", resp.Status)
+	}
+
+	// Read one byte past the expected size to catch oversized objects.
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, p.Size+1)); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	if int64(buf.Len()) != p.Size || hex.EncodeToString(sum[:]) != p.OID {
+		return fmt.Errorf("lfs object %s does not match its pointer", p.OID)
+	}
+
+	f, err := fs.Create(p.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a Language Server Protocol server publishing secret findings as diagnostics",
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &lspServer{out: os.Stdout}
+		if err := s.serve(os.Stdin); err != nil {
+			logrus.Error("lsp: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// lspServer is a minimal LSP server over stdio. It syncs full documents and
+// publishes a diagnostic for every finding in open files, honoring the
+// workspace's .credignore.
+type lspServer struct {
+	out      io.Writer
+	rootPath string
+	ignore   map[string]struct{}
+}
+
+type lspMessage struct {
+	ID     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type lspTextDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspDiagnostic struct {
+	Range struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// LSP diagnostic severity for warnings.
+const lspSeverityWarning = 2
+
+// serve handles requests from r until the client sends exit or closes r.
+func (s *lspServer) serve(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		body, err := readLSPMessage(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return err
+		}
+
+		switch msg.Method {
+		case "initialize":
+			var params struct {
+				RootURI string `json:"rootUri"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			s.rootPath = uriToPath(params.RootURI)
+			s.ignore = loadCredIgnore(s.rootPath)
+			err = s.respond(msg.ID, map[string]interface{}{
+				"capabilities": map[string]interface{}{
+					// Full document sync.
+					"textDocumentSync": map[string]interface{}{"openClose": true, "change": 1},
+				},
+				"serverInfo": map[string]string{"name": "skrt"},
+			})
+		case "textDocument/didOpen":
+			var params struct {
+				TextDocument lspTextDocument `json:"textDocument"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			err = s.publish(params.TextDocument.URI, params.TextDocument.Text)
+		case "textDocument/didChange":
+			var params struct {
+				TextDocument   lspTextDocument `json:"textDocument"`
+				ContentChanges []struct {
+					Text string `json:"text"`
+				} `json:"contentChanges"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			if n := len(params.ContentChanges); n != 0 {
+				err = s.publish(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+			}
+		case "textDocument/didClose":
+			var params struct {
+				TextDocument lspTextDocument `json:"textDocument"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			err = s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+				"uri":         params.TextDocument.URI,
+				"diagnostics": []lspDiagnostic{},
+			})
+		case "shutdown":
+			err = s.respond(msg.ID, nil)
+		case "exit":
+			return nil
+		default:
+			// Unsupported requests must still be answered.
+			if msg.ID != nil {
+				err = s.write(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      msg.ID,
+					"error":   map[string]interface{}{"code": -32601, "message": "method not found"},
+				})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// publish checks text, the contents of the document at uri, and publishes
+// its findings as diagnostics.
+func (s *lspServer) publish(uri, text string) error {
+	diagnostics := []lspDiagnostic{}
+
+	relPath := uriToPath(uri)
+	if s.rootPath != "" {
+		if rel, err := filepath.Rel(s.rootPath, relPath); err == nil {
+			relPath = rel
+		}
+	}
+	if _, ignored := s.ignore[relPath]; !ignored {
+		data := []byte(text)
+		for _, pos := range scanFileData(filepath.ToSlash(relPath), data) {
+			var d lspDiagnostic
+			d.Range.Start = offsetToLSPPosition(data, pos.Start)
+			d.Range.End = offsetToLSPPosition(data, pos.End)
+			d.Severity = lspSeverityWarning
+			d.Source = "skrt"
+			d.Message = "Possible secret (" + pos.Rule + ")"
+			if pos.Remediation != "" {
+				d.Message += ": " + pos.Remediation
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	return s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+func (s *lspServer) respond(id *json.RawMessage, result interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *lspServer) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *lspServer) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// readLSPMessage reads one base protocol message body from br.
+func readLSPMessage(br *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v := strings.TrimPrefix(line, "Content-Length:"); v != line {
+			if length, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(br, body)
+	return body, err
+}
+
+// offsetToLSPPosition converts a byte offset in data to a line and UTF-16
+// character offset, as LSP positions require.
+func offsetToLSPPosition(data []byte, offset int) (pos lspPosition) {
+	lineStart := 0
+	for i := 0; i < offset; i++ {
+		if data[i] == '\n' {
+			pos.Line++
+			lineStart = i + 1
+		}
+	}
+	for i := lineStart; i < offset; {
+		r, size := utf8.DecodeRune(data[i:])
+		pos.Character++
+		if r >= 0x10000 {
+			pos.Character++
+		}
+		i += size
+	}
+	return pos
+}
+
+// uriToPath converts a file:// URI to a local path.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// loadCredIgnore returns the files listed in root's .credignore, if any.
+func loadCredIgnore(root string) map[string]struct{} {
+	ignore := map[string]struct{}{credIgnoreFile: {}}
+	data, err := ioutil.ReadFile(filepath.Join(root, credIgnoreFile))
+	if err != nil {
+		return ignore
+	}
+	parseCredIgnore(data, ignore)
+	return ignore
+}
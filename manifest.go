@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Print a JSON inventory of credential types found per repo, without values",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newClient(ctx)
+		if err := preflight(ctx, client, orgName); err != nil {
+			logrus.Error("manifest: ", err)
+			os.Exit(1)
+		}
+
+		m := &Manifest{Org: orgName, GeneratedAt: time.Now().UTC(), Totals: map[string]int{}}
+		if err := CrawlOrg(ctx, client, orgName, m); err != nil {
+			logrus.Error("manifest: ", err)
+			os.Exit(1)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			logrus.Error("manifest: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Manifest is an exposure inventory of an org: how many findings of each
+// credential type (rule) each repo has. It contains no matched data or
+// locations, so it can be shared as compliance evidence.
+type Manifest struct {
+	Org         string         `json:"org"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Repos       []ManifestRepo `json:"repos"`
+	Totals      map[string]int `json:"totals"`
+}
+
+// ManifestRepo counts one repo's findings by rule. Directories and
+// Extensions count them by top-level directory ("." for files at the root)
+// and file extension ("" for none), showing where in a repo leaks
+// concentrate. ScanErrors is the number of parts of the repo that could not
+// be checked, and RetriableErrors how many of those failed transiently, so a
+// rescan may check them.
+type ManifestRepo struct {
+	Name        string         `json:"name"`
+	Counts      map[string]int `json:"counts"`
+	Directories map[string]int `json:"directories"`
+	Extensions  map[string]int `json:"extensions"`
+	ScanErrors  int            `json:"scan_errors,omitempty"`
+
+	RetriableErrors int `json:"retriable_errors,omitempty"`
+}
+
+// WriteRepo adds sr's finding counts to m.
+func (m *Manifest) WriteRepo(sr SensitiveRepo) error {
+	mr := ManifestRepo{
+		Name:        sr.Name,
+		Counts:      map[string]int{},
+		Directories: map[string]int{},
+		Extensions:  map[string]int{},
+		ScanErrors:  len(sr.Errors),
+	}
+	err := sr.eachFile(func(file SensitiveFile) error {
+		slashPath := filepath.ToSlash(file.Path)
+		dir, ext := topLevelDir(slashPath), path.Ext(slashPath)
+		for _, pos := range file.Positions {
+			mr.Counts[pos.Rule]++
+			mr.Directories[dir]++
+			mr.Extensions[ext]++
+			m.Totals[pos.Rule]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range sr.Errors {
+		if e.Retriable {
+			mr.RetriableErrors++
+		}
+	}
+	m.Repos = append(m.Repos, mr)
+	return nil
+}
+
+// topLevelDir returns the first directory in the slash-separated path p, or
+// "." if p is a file at the root.
+func topLevelDir(p string) string {
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		return p[:i]
+	}
+	return "."
+}
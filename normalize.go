@@ -0,0 +1,99 @@
+package main
+
+import (
+	"unicode/utf8"
+)
+
+// Characters that render as nothing and are removed before matching.
+var zeroWidth = map[rune]bool{
+	'\u00ad': true, // Soft hyphen.
+	'\u180e': true, // Mongolian vowel separator.
+	'\u200b': true, // Zero width space.
+	'\u200c': true, // Zero width non-joiner.
+	'\u200d': true, // Zero width joiner.
+	'\u2060': true, // Word joiner.
+	'\ufeff': true, // Zero width no-break space.
+}
+
+// homoglyphs maps non-ASCII letters to the ASCII letters they are visually
+// indistinguishable from.
+var homoglyphs = map[rune]byte{
+	// Cyrillic.
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'і': 'i', 'ј': 'j', 'ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O',
+	'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X', 'І': 'I', 'Ј': 'J', 'Ѕ': 'S',
+	// Greek.
+	'ο': 'o', 'ν': 'v', 'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H',
+	'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T',
+	'Υ': 'Y', 'Χ': 'X',
+}
+
+// textMapping maps byte i of normalized text to the original bytes
+// [starts[i], ends[i]).
+type textMapping struct {
+	starts, ends []int
+}
+
+// normalizeText strips zero-width characters from data and maps homoglyphs
+// and fullwidth forms to ASCII, so trivially obfuscated secrets still match
+// rules. If data is ASCII, it is returned as is with a nil mapping.
+func normalizeText(data []byte) ([]byte, *textMapping) {
+	if isASCII(data) {
+		return data, nil
+	}
+
+	normalized := make([]byte, 0, len(data))
+	m := &textMapping{
+		starts: make([]int, 0, len(data)),
+		ends:   make([]int, 0, len(data)),
+	}
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case zeroWidth[r]:
+		case homoglyphs[r] != 0:
+			normalized = append(normalized, homoglyphs[r])
+			m.starts = append(m.starts, i)
+			m.ends = append(m.ends, i+size)
+		case r >= '\uff01' && r <= '\uff5e':
+			// Fullwidth ASCII variants.
+			normalized = append(normalized, byte(r-'\uff01'+'!'))
+			m.starts = append(m.starts, i)
+			m.ends = append(m.ends, i+size)
+		default:
+			for j := i; j < i+size; j++ {
+				normalized = append(normalized, data[j])
+				m.starts = append(m.starts, j)
+				m.ends = append(m.ends, j+1)
+			}
+		}
+		i += size
+	}
+	return normalized, m
+}
+
+// denormalize converts positions in normalized text back to positions in the
+// original data.
+func (m *textMapping) denormalize(positions []SensitivePos) []SensitivePos {
+	if m == nil {
+		return positions
+	}
+	for i, pos := range positions {
+		if pos.End <= pos.Start {
+			continue
+		}
+		positions[i].Start = m.starts[pos.Start]
+		positions[i].End = m.ends[pos.End-1]
+	}
+	return positions
+}
+
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Branch onboarding changes are committed to.
+const onboardBranch = "seekret-onboarding"
+
+// Path of the GitHub Actions workflow added by onboarding.
+const onboardWorkflowPath = ".github/workflows/seekret.yml"
+
+const starterCredIgnore = `# Files listed here, one per line relative to the repo root, are not checked
+# for sensitive data by seekret. Only list files whose credentials are known
+# to be fake or public, and say why in a comment.
+#
+# Example:
+# testdata/fake-credentials.json
+`
+
+// onboardWorkflow downloads the linux/amd64 binary of a seekret release, as
+// make release builds them, so scans need no Go toolchain. The scan checks
+// only the repo itself, which the workflow's GITHUB_TOKEN can read.
+const onboardWorkflow = `name: seekret
+on:
+  pull_request:
+jobs:
+  seekret:
+    runs-on: ubuntu-latest
+    env:
+      # Pin to a release tag, ex. v1.2.0, to upgrade deliberately.
+      SKRT_VERSION: latest
+    steps:
+      - name: Install skrt
+        run: |
+          if [ "$SKRT_VERSION" = latest ]; then
+            url=https://github.com/estroz/seekret/releases/latest/download/skrt-linux-amd64
+          else
+            url=https://github.com/estroz/seekret/releases/download/$SKRT_VERSION/skrt-linux-amd64
+          fi
+          curl -fsSL -o skrt "$url"
+          chmod +x skrt
+      - name: Scan for secrets
+        env:
+          TOKEN: ${{ secrets.GITHUB_TOKEN }}
+        run: |
+          ./skrt --repo "${{ github.repository }}" --oauth-token "$TOKEN" --quick --output-file results.jsonl
+          if [ -s results.jsonl ]; then cat results.jsonl; exit 1; fi
+`
+
+var onboardCmd = &cobra.Command{
+	Use:   "onboard",
+	Short: "Open a pull request adding seekret configuration to a repo",
+	Run: func(cmd *cobra.Command, args []string) {
+		owner, name, err := splitRepoFullName(repoFullName)
+		if err != nil {
+			logrus.Error("onboard: ", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		client := newClient(ctx)
+		pr, err := Onboard(ctx, client, owner, name)
+		if err != nil {
+			logrus.Error("onboard: ", err)
+			os.Exit(1)
+		}
+		fmt.Println(pr.GetHTMLURL())
+	},
+}
+
+func init() {
+	onboardCmd.Flags().StringVar(&repoFullName, "repo", "", "Repo to onboard, as owner/name.")
+}
+
+// Onboard opens a pull request against owner/name's default branch adding a
+// starter .credignore, unless one exists, and a workflow scanning every pull
+// request with skrt. The PR description includes a status badge to add to
+// the README.
+func Onboard(ctx context.Context, client *github.Client, owner, name string) (*github.PullRequest, error) {
+	repo, _, err := client.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+	base := repo.GetDefaultBranch()
+
+	ref, _, err := client.Git.GetRef(ctx, owner, name, "heads/"+base)
+	if err != nil {
+		return nil, err
+	}
+	_, _, err = client.Git.CreateRef(ctx, owner, name, &github.Reference{
+		Ref:    github.String("refs/heads/" + onboardBranch),
+		Object: ref.Object,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create branch %s: %v", onboardBranch, err)
+	}
+
+	files := []struct{ path, content string }{
+		{onboardWorkflowPath, onboardWorkflow},
+	}
+	_, _, resp, err := client.Repositories.GetContents(ctx, owner, name, credIgnoreFile, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		files = append(files, struct{ path, content string }{credIgnoreFile, starterCredIgnore})
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		_, _, err := client.Repositories.CreateFile(ctx, owner, name, f.path, &github.RepositoryContentFileOptions{
+			Message: github.String("Add " + f.path + " for seekret"),
+			Content: []byte(f.content),
+			Branch:  github.String(onboardBranch),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %v", f.path, err)
+		}
+	}
+
+	badge := fmt.Sprintf("[![seekret](https://github.com/%s/%s/actions/workflows/seekret.yml/badge.svg)](https://github.com/%s/%s/actions/workflows/seekret.yml)",
+		owner, name, owner, name)
+	pr, _, err := client.PullRequests.Create(ctx, owner, name, &github.NewPullRequest{
+		Title: github.String("Add seekret secret scanning"),
+		Head:  github.String(onboardBranch),
+		Base:  github.String(base),
+		Body: github.String("This adds a workflow that scans each pull request for committed secrets with seekret, " +
+			"and a starter .credignore for files with known-fake credentials.\n\n" +
+			"Add this badge to the README to show the scan status:\n\n    " + badge + "\n"),
+	})
+	return pr, err
+}
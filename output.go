@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+)
+
+// Values of --output.
+const (
+	outputRepos    = "repos"
+	outputFindings = "jsonl"
+)
+
+// ResultWriter receives each repo's scan results as soon as the repo has been
+// scanned, so results survive a crash later in the scan.
+type ResultWriter interface {
+	WriteRepo(SensitiveRepo) error
+}
+
+// cleanRepoWriter is a ResultWriter also receiving repos with nothing to
+// report, ex. to account for every file checked.
+type cleanRepoWriter interface {
+	ResultWriter
+	WriteCleanRepo(SensitiveRepo) error
+}
+
+// jsonLinesWriter writes one JSON object per repo per line, syncing after
+// each so the results are durable once WriteRepo returns.
+type jsonLinesWriter struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// newJSONLinesWriter writes results to the file at path, or stdout if path is
+// empty. The file is appended to if it exists.
+func newJSONLinesWriter(path string) (*jsonLinesWriter, error) {
+	f := os.Stdout
+	if path != "" {
+		var err error
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &jsonLinesWriter{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (w *jsonLinesWriter) WriteRepo(sr SensitiveRepo) error {
+	if sr.spill != nil {
+		return w.writeSpilled(sr)
+	}
+	return w.write(sr)
+}
+
+// writeSpilled writes sr, some of whose files were spilled to disk, as write
+// would, encoding its files one at a time so they need not all be in memory.
+func (w *jsonLinesWriter) writeSpilled(sr SensitiveRepo) error {
+	files := sr.Files
+	sr.Files = []SensitiveFile{}
+	b, err := json.Marshal(sr)
+	if err != nil {
+		return err
+	}
+	sr.Files = files
+	// Names are quoted strings, so the first empty files array is sr's.
+	split := bytes.Index(b, []byte(`"files":[]`)) + len(`"files":[`)
+
+	bw := bufio.NewWriter(w.f)
+	bw.Write(b[:split])
+	n := 0
+	err = sr.eachFile(func(f SensitiveFile) error {
+		fb, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		n++
+		_, err = bw.Write(fb)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.Write(b[split:])
+	bw.WriteByte('\n')
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return w.sync()
+}
+
+// write writes v as one line and syncs it.
+func (w *jsonLinesWriter) write(v interface{}) error {
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	return w.sync()
+}
+
+// sync syncs the underlying file.
+func (w *jsonLinesWriter) sync() error {
+	// Stdout may be a pipe or terminal, which cannot be synced.
+	if w.f == os.Stdout {
+		return nil
+	}
+	return w.f.Sync()
+}
+
+// Close closes the underlying file unless it is stdout.
+func (w *jsonLinesWriter) Close() error {
+	if w.f == os.Stdout {
+		return nil
+	}
+	return w.f.Close()
+}
+
+// fileStream, if set, receives each checked-out file's findings as soon as
+// the file has been checked, before the rest of its repo.
+var fileStream func(repo string, f SensitiveFile)
+
+// findingLinesWriter writes one JSON object per finding per line, with the
+// finding's repo, path, and commit, one per scan error, and one per repo of a
+// kind reported distinctly, ex. an empty repo. If streaming is set, findings
+// in repos' checkouts are written by WriteFile as each file is checked, and
+// WriteRepo only writes the rest.
+type findingLinesWriter struct {
+	*jsonLinesWriter
+	streaming bool
+}
+
+type findingLine struct {
+	Repo   string `json:"repo"`
+	Path   string `json:"path"`
+	Commit string `json:"commit,omitempty"`
+	SensitivePos
+}
+
+type errorLine struct {
+	Repo string `json:"repo"`
+	ScanError
+}
+
+// clusterLine is a pattern cluster, written after all repos' results.
+type clusterLine struct {
+	PatternCluster PatternCluster `json:"pattern_cluster"`
+}
+
+type kindsLine struct {
+	Repo        string   `json:"repo"`
+	Kinds       []string `json:"kinds"`
+	LFSPointers int      `json:"lfs_pointers,omitempty"`
+}
+
+// WriteFile writes the findings in f, a file in repo.
+func (w *findingLinesWriter) WriteFile(repo string, f SensitiveFile) error {
+	for _, pos := range f.Positions {
+		if err := w.write(findingLine{Repo: repo, Path: f.Path, Commit: f.Commit, SensitivePos: pos}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *findingLinesWriter) WriteRepo(sr SensitiveRepo) error {
+	if sr.Kinds != nil {
+		if err := w.write(kindsLine{Repo: sr.Name, Kinds: sr.Kinds, LFSPointers: sr.LFSPointers}); err != nil {
+			return err
+		}
+	}
+	err := sr.eachFile(func(f SensitiveFile) error {
+		// Findings in past commits are not found by file checks.
+		if w.streaming && f.Commit == "" {
+			return nil
+		}
+		return w.WriteFile(sr.Name, f)
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range sr.Errors {
+		if err := w.write(errorLine{Repo: sr.Name, ScanError: e}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/estroz/seekret/detection"
+	"github.com/sirupsen/logrus"
+)
+
+// Longest a plugin may take to scan one file before it is killed.
+const pluginTimeout = 30 * time.Second
+
+// pluginFinding is a finding as plugins write them.
+type pluginFinding struct {
+	Rule        string            `json:"rule"`
+	Start       int               `json:"start"`
+	End         int               `json:"end"`
+	Remediation string            `json:"remediation"`
+	Details     map[string]string `json:"details"`
+}
+
+// execDetector is a Detector running an external executable, a plugin, on
+// each file. The plugin is given the file's content on stdin and writes a
+// JSON array of its findings to stdout, each an object with a rule, the
+// start and end byte offsets of the secret, and optionally a remediation and
+// string details, ex. [{"rule": "acme-token", "start": 10, "end": 42}]. No
+// output is no findings. Plugins that fail, time out, or write invalid
+// findings are logged, and their invalid findings dropped.
+type execDetector struct {
+	path string
+}
+
+// newExecDetector returns a detector running the plugin at path, which is
+// looked up in PATH if it has no separators.
+func newExecDetector(path string) (*execDetector, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", path, err)
+	}
+	return &execDetector{path: resolved}, nil
+}
+
+func (d *execDetector) Name() string { return "plugin:" + filepath.Base(d.path) }
+
+func (d *execDetector) Scan(data []byte) []detection.Finding {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, d.path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		logrus.Warnf("%s: %v", d.Name(), err)
+		return nil
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+
+	var pfs []pluginFinding
+	if err := json.Unmarshal(stdout.Bytes(), &pfs); err != nil {
+		logrus.Warnf("%s: read findings: %v", d.Name(), err)
+		return nil
+	}
+	findings := make([]detection.Finding, 0, len(pfs))
+	for _, pf := range pfs {
+		if pf.Rule == "" || pf.Start < 0 || pf.End <= pf.Start || pf.End > len(data) {
+			logrus.Warnf("%s: invalid finding %+v", d.Name(), pf)
+			continue
+		}
+		findings = append(findings, detection.Finding{
+			Rule:        pf.Rule,
+			Start:       pf.Start,
+			End:         pf.End,
+			Remediation: pf.Remediation,
+			Details:     pf.Details,
+		})
+	}
+	return findings
+}
+
+// registerPlugins registers a detector for each of paths, plugins as
+// execDetector runs.
+func registerPlugins(paths []string) error {
+	for _, path := range paths {
+		d, err := newExecDetector(path)
+		if err != nil {
+			return err
+		}
+		detection.Register(d)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+)
+
+// Minimum time between starting consecutive repos when unauthenticated, to
+// stay clear of anonymous clone throttling.
+const politeRepoDelay = 2 * time.Second
+
+// warnUnauthenticated explains the anonymous API limits and how many
+// requests remain, and suggests using a token.
+func warnUnauthenticated(ctx context.Context, client *github.Client) {
+	limits, _, err := client.RateLimits(ctx)
+	if err != nil || limits.Core == nil {
+		logrus.Warn("No OAuth token given; scanning with anonymous API limits. Use --oauth-token or 'skrt auth login' for higher limits.")
+		return
+	}
+	logrus.Warnf("No OAuth token given; anonymous API limit is %d requests/hour (%d remaining, resets %s). "+
+		"Use --oauth-token or 'skrt auth login' for higher limits.",
+		limits.Core.Limit, limits.Core.Remaining, limits.Core.Reset.Format(time.Kitchen))
+}
+
+// logPoliteEstimate logs a lower bound on the time to scan n repos at the
+// unauthenticated pace.
+func logPoliteEstimate(n int) {
+	estimate := time.Duration(n) * politeRepoDelay
+	logrus.Infof("Unauthenticated: starting at most one repo every %s; %d repos will take at least %s.",
+		politeRepoDelay, n, estimate)
+}
+
+// politeTransport delays requests once the API rate limit is exhausted
+// until it resets, instead of letting them fail.
+type politeTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+}
+
+func newPoliteTransport() *politeTransport {
+	return &politeTransport{base: http.DefaultTransport, remaining: -1}
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	wait := time.Duration(0)
+	if t.remaining == 0 {
+		wait = time.Until(t.reset)
+	}
+	t.mu.Unlock()
+
+	if wait > 0 {
+		logrus.Infof("API rate limit exhausted; waiting %s for it to reset.", wait.Round(time.Second))
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, rerr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, serr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if rerr == nil && serr == nil {
+		t.mu.Lock()
+		t.remaining = remaining
+		t.reset = time.Unix(reset, 0)
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/go-github/github"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var postureCmd = &cobra.Command{
+	Use:   "posture",
+	Short: "Report repos missing secret scanning, push protection, or branch protection",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newClient(ctx)
+
+		if err := preflight(ctx, client, orgName, "repo", "read:org"); err != nil {
+			logrus.Error("posture: ", err)
+			os.Exit(1)
+		}
+		if err := Posture(ctx, client, orgName, os.Stdout); err != nil {
+			logrus.Error("posture: ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// securityAndAnalysis is the subset of a repository's security_and_analysis
+// API field relevant to secrets. go-github does not expose it, so it is
+// requested directly.
+type securityAndAnalysis struct {
+	SecurityAndAnalysis struct {
+		SecretScanning struct {
+			Status string `json:"status"`
+		} `json:"secret_scanning"`
+		SecretScanningPushProtection struct {
+			Status string `json:"status"`
+		} `json:"secret_scanning_push_protection"`
+	} `json:"security_and_analysis"`
+}
+
+// Posture checks each repo in orgName for secret scanning, push protection,
+// default branch protection, and signed-commit requirements, and writes a
+// table of the results to w. Settings the token is not permitted to read are
+// reported as "unknown".
+func Posture(ctx context.Context, client *github.Client, orgName string, w io.Writer) error {
+	repos, err := listOrgRepos(ctx, client, orgName, "all")
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSECRET SCANNING\tPUSH PROTECTION\tBRANCH PROTECTION\tSIGNED COMMITS")
+	for _, repo := range repos {
+		if repo.Name == nil || *repo.Name == "" {
+			continue
+		}
+		repoName := *repo.Name
+		branch := repo.GetDefaultBranch()
+
+		scanning, pushProtection := "unknown", "unknown"
+		var sa securityAndAnalysis
+		u := fmt.Sprintf("repos/%s/%s", orgName, repoName)
+		if req, err := client.NewRequest("GET", u, nil); err == nil {
+			if _, err := client.Do(ctx, req, &sa); err == nil {
+				scanning = enabledStatus(sa.SecurityAndAnalysis.SecretScanning.Status)
+				pushProtection = enabledStatus(sa.SecurityAndAnalysis.SecretScanningPushProtection.Status)
+			} else {
+				logrus.Warnf("Posture: get repo %s: %v", repoName, err)
+			}
+		}
+
+		protection, signatures := "unknown", "unknown"
+		if branch != "" {
+			_, resp, err := client.Repositories.GetBranchProtection(ctx, orgName, repoName, branch)
+			protection = presenceStatus(resp, err)
+			if err != nil && protection == "unknown" {
+				logrus.Warnf("Posture: GetBranchProtection %s: %v", repoName, err)
+			}
+
+			// An unprotected branch cannot require signatures.
+			if protection == "no" {
+				signatures = "no"
+			} else {
+				u := fmt.Sprintf("repos/%s/%s/branches/%s/protection/required_signatures", orgName, repoName, branch)
+				if req, err := client.NewRequest("GET", u, nil); err == nil {
+					req.Header.Set("Accept", "application/vnd.github.zzzax-preview+json")
+					resp, err := client.Do(ctx, req, nil)
+					signatures = presenceStatus(resp, err)
+				}
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			repoName, scanning, pushProtection, protection, signatures)
+	}
+
+	return tw.Flush()
+}
+
+// enabledStatus converts a security_and_analysis status to a table value.
+func enabledStatus(status string) string {
+	switch status {
+	case "enabled":
+		return "yes"
+	case "disabled":
+		return "no"
+	}
+	return "unknown"
+}
+
+// presenceStatus reports whether a protection endpoint returned a resource.
+// GitHub returns 404 when the protection is not configured.
+func presenceStatus(resp *github.Response, err error) string {
+	switch {
+	case err == nil:
+		return "yes"
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return "no"
+	}
+	return "unknown"
+}
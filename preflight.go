@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// impliedScopes maps an OAuth scope to broader scopes that also grant it.
+var impliedScopes = map[string][]string{
+	"public_repo": {"repo"},
+	"read:org":    {"write:org", "admin:org"},
+	"write:org":   {"admin:org"},
+}
+
+// preflight validates accessToken before a crawl of orgName: the token must
+// be valid, carry every scope in required, and be SSO-authorized for the org.
+// Without a token there is nothing to validate. Fine-grained tokens do not
+// report scopes, so only their SSO authorization is checked.
+func preflight(ctx context.Context, client *github.Client, orgName string, required ...string) error {
+	if accessToken == "" {
+		return nil
+	}
+
+	_, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("preflight: OAuth token is invalid or expired")
+		}
+		return fmt.Errorf("preflight: get authenticated user: %v", err)
+	}
+
+	if header, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		granted := make(map[string]bool)
+		for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+			granted[strings.TrimSpace(scope)] = true
+		}
+		var missing []string
+		for _, scope := range required {
+			if !hasScope(granted, scope) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) != 0 {
+			return fmt.Errorf("preflight: OAuth token is missing scopes: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	// Orgs enforcing SAML SSO reject, or silently filter results for, tokens
+	// not authorized for them, and say so in the X-GitHub-SSO header.
+	opt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 1}}
+	_, resp, err = client.Repositories.ListByOrg(ctx, orgName, opt)
+	if resp != nil {
+		if sso := resp.Header.Get("X-GitHub-SSO"); sso != "" {
+			return fmt.Errorf("preflight: OAuth token is not SSO-authorized for org %q (%s)", orgName, sso)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("preflight: list repos in org %q: %v", orgName, err)
+	}
+
+	return nil
+}
+
+// preflightRepo validates accessToken before a crawl of the single repo
+// owner/name: the token must be able to read the repo, and be SSO-authorized
+// for its owner. Unlike preflight, it neither reads the authenticated user
+// nor lists an org's repos, so it works with GitHub Actions' and apps'
+// installation tokens, and for repos owned by users.
+func preflightRepo(ctx context.Context, client *github.Client, owner, name string) error {
+	if accessToken == "" {
+		return nil
+	}
+
+	_, resp, err := client.Repositories.Get(ctx, owner, name)
+	if resp != nil {
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("preflight: OAuth token is invalid or expired")
+		}
+		if sso := resp.Header.Get("X-GitHub-SSO"); sso != "" {
+			return fmt.Errorf("preflight: OAuth token is not SSO-authorized for %q (%s)", owner, sso)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("preflight: get repo %s/%s: %v", owner, name, err)
+	}
+	return nil
+}
+
+// hasScope reports whether scope, or a scope implying it, is granted.
+func hasScope(granted map[string]bool, scope string) bool {
+	if granted[scope] {
+		return true
+	}
+	for _, broader := range impliedScopes[scope] {
+		if hasScope(granted, broader) {
+			return true
+		}
+	}
+	return false
+}
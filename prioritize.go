@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Topics and languages that indicate a repo holds infrastructure or
+// deployment code, which is more likely to contain credentials.
+var (
+	riskyTopics = []string{
+		"infra", "terraform", "ansible", "kubernetes", "k8s", "helm", "deploy",
+		"devops", "ci", "cd", "docker", "aws", "gcp", "azure", "secrets", "config",
+	}
+	riskyLanguages = map[string]bool{
+		"HCL":        true,
+		"Shell":      true,
+		"PowerShell": true,
+		"Dockerfile": true,
+		"Puppet":     true,
+		"Nix":        true,
+		"Jsonnet":    true,
+	}
+)
+
+// Lower-cased full names, owner/name, of repos with findings saved to the
+// --store by past scans, other than ignored ones. Repos of other owners with
+// the same name are not confused with them.
+var pastFindingRepos map[string]bool
+
+// loadPastFindings sets pastFindingRepos from the findings saved to store.
+func loadPastFindings(store Store) error {
+	findings, err := store.QueryFindings(FindingQuery{})
+	if err != nil {
+		return err
+	}
+	pastFindingRepos = make(map[string]bool)
+	for _, f := range findings {
+		if f.Status != statusIgnored {
+			pastFindingRepos[strings.ToLower(f.Repo)] = true
+		}
+	}
+	return nil
+}
+
+// prioritizeRepos orders repos so those most likely to contain secrets are
+// scanned first: repos with past findings, recently pushed repos, and repos
+// with infrastructure-related topics or languages. The sort is stable, so equally risky repos keep their
+// API order.
+func prioritizeRepos(repos []*github.Repository, now time.Time) {
+	scores := make(map[*github.Repository]int, len(repos))
+	for _, repo := range repos {
+		scores[repo] = repoRisk(repo, now)
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return scores[repos[i]] > scores[repos[j]]
+	})
+}
+
+// repoRisk scores repo by heuristics; higher is riskier.
+func repoRisk(repo *github.Repository, now time.Time) (score int) {
+	if pastFindingRepos[strings.ToLower(repo.GetFullName())] {
+		score += 3
+	}
+
+	if repo.PushedAt != nil {
+		switch age := now.Sub(repo.GetPushedAt().Time); {
+		case age < 7*24*time.Hour:
+			score += 3
+		case age < 30*24*time.Hour:
+			score += 2
+		case age < 90*24*time.Hour:
+			score++
+		}
+	}
+
+	if riskyLanguages[repo.GetLanguage()] {
+		score += 2
+	}
+
+	for _, topic := range repo.Topics {
+		if hasRiskyTopic(topic) {
+			score += 2
+			break
+		}
+	}
+
+	return score
+}
+
+// hasRiskyTopic reports whether topic, or any dash-separated part of it, is a
+// risky topic, ex. "terraform-modules".
+func hasRiskyTopic(topic string) bool {
+	for _, part := range strings.Split(strings.ToLower(topic), "-") {
+		for _, risky := range riskyTopics {
+			if part == risky {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// Branch quarantine commits are pushed to.
+const quarantineBranch = "seekret-quarantine"
+
+// Text verified secrets are replaced with on the quarantine branch.
+const quarantinePlaceholder = "REMOVED-BY-SEEKRET"
+
+// Labels marking quarantine PRs for responders.
+var quarantineLabels = []string{"security", "priority: critical"}
+
+// quarantineSecrets responds to verified, critical findings in files, found
+// in the checkout of repo's default branch in fs: it commits a copy of each
+// affected file with every such secret replaced by quarantinePlaceholder to
+// quarantineBranch, and opens a pull request of the commit against the
+// default branch. Files are edited as they are on the default branch now, so
+// pushes since the scan are kept. Findings in past commits are skipped. A nil
+// PR is returned if there is nothing to quarantine.
+func quarantineSecrets(ctx context.Context, client *github.Client, repo *github.Repository, fs billy.Filesystem, files []SensitiveFile) (*github.PullRequest, error) {
+	// Secrets to replace, by path.
+	secrets := make(map[string][]string)
+	var rules []string
+	for _, f := range files {
+		if f.Commit != "" {
+			continue
+		}
+		var data []byte
+		for _, pos := range f.Positions {
+			if !pos.Verified || pos.Severity != severityVerified {
+				continue
+			}
+			if data == nil {
+				var err error
+				if data, err = readFSFile(fs, f.Path); err != nil {
+					return nil, err
+				}
+			}
+			// Only the value of an assignment is replaced, so the file
+			// still reads as before.
+			secret := assignedValue(data[pos.Start:pos.End])
+			if secret == "" {
+				secret = string(data[pos.Start:pos.End])
+			}
+			secrets[f.Path] = appendUnique(secrets[f.Path], secret)
+			rules = appendUnique(rules, pos.Rule)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+
+	owner, name, base := repo.GetOwner().GetLogin(), repo.GetName(), repo.GetDefaultBranch()
+	ref, _, err := client.Git.GetRef(ctx, owner, name, "heads/"+base)
+	if err != nil {
+		return nil, err
+	}
+	parent, _, err := client.Git.GetCommit(ctx, owner, name, ref.Object.GetSHA())
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for p := range secrets {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var entries []github.TreeEntry
+	for _, p := range paths {
+		slashPath := filepath.ToSlash(p)
+		fc, _, _, err := client.Repositories.GetContents(ctx, owner, name, slashPath, &github.RepositoryContentGetOptions{Ref: parent.GetSHA()})
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %v", p, err)
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %v", p, err)
+		}
+		for _, secret := range secrets[p] {
+			content = strings.Replace(content, secret, quarantinePlaceholder, -1)
+		}
+		// Executable files stay executable.
+		mode := "100644"
+		if info, err := fs.Stat(p); err == nil && info.Mode()&0111 != 0 {
+			mode = "100755"
+		}
+		entries = append(entries, github.TreeEntry{
+			Path:    github.String(slashPath),
+			Mode:    github.String(mode),
+			Type:    github.String("blob"),
+			Content: github.String(content),
+		})
+	}
+
+	tree, _, err := client.Git.CreateTree(ctx, owner, name, parent.Tree.GetSHA(), entries)
+	if err != nil {
+		return nil, fmt.Errorf("create tree: %v", err)
+	}
+	commit, _, err := client.Git.CreateCommit(ctx, owner, name, &github.Commit{
+		Message: github.String("Remove verified secrets\n\nReplaces live credentials found by seekret with a placeholder."),
+		Tree:    tree,
+		Parents: []github.Commit{{SHA: parent.SHA}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create commit: %v", err)
+	}
+	_, _, err = client.Git.CreateRef(ctx, owner, name, &github.Reference{
+		Ref:    github.String("refs/heads/" + quarantineBranch),
+		Object: &github.GitObject{SHA: commit.SHA},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create branch %s: %v", quarantineBranch, err)
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, name, &github.NewPullRequest{
+		Title: github.String("[URGENT] Remove verified live secrets"),
+		Head:  github.String(quarantineBranch),
+		Base:  github.String(base),
+		Body: github.String("seekret found credentials on " + base + " that their providers confirmed are live (" +
+			strings.Join(rules, ", ") + ") in:\n\n- " + strings.Join(paths, "\n- ") + "\n\n" +
+			"This replaces them with a placeholder. Rotate each credential first: merging this does not revoke them, " +
+			"and they remain in the repo's history. Then point the code at the new credentials' source, " +
+			"such as the environment or a secrets manager, before merging.\n"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open pull request: %v", err)
+	}
+	if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, name, pr.GetNumber(), quarantineLabels); err != nil {
+		return pr, fmt.Errorf("label pull request: %v", err)
+	}
+	return pr, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"time"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+const (
+	// Commits fetched per repo in quick mode, bounding how far back recently
+	// changed files can be found.
+	quickCloneDepth = 50
+	// Files larger than this are skipped in quick mode.
+	quickMaxFileSize = 1 << 20
+	// Time allowed per repo in quick mode, including the clone.
+	quickRepoTimeout = time.Minute
+	// Rules less specific than this are not reported in quick mode, leaving
+	// only high-confidence rules.
+	quickMinSpecificity = 0.85
+)
+
+// dropUnspecific removes positions of rules less specific than
+// quickMinSpecificity.
+func dropUnspecific(positions []SensitivePos) []SensitivePos {
+	kept := positions[:0]
+	for _, pos := range positions {
+		if specificity(pos.Rule) >= quickMinSpecificity {
+			kept = append(kept, pos)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// recentlyChangedFiles returns the slash-separated paths of files added or
+// modified by commits on HEAD made after since. The walk stops at the first
+// older commit or at the boundary of a shallow clone.
+func recentlyChangedFiles(r *git.Repository, since time.Time) (map[string]bool, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for commit.Committer.When.After(since) {
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		// Root commits and shallow boundaries have no parent to diff against,
+		// so all of their files count as changed.
+		parent, err := commit.Parent(0)
+		if err != nil {
+			err = tree.Files().ForEach(func(f *object.File) error {
+				files[f.Name] = true
+				return nil
+			})
+			return files, err
+		}
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			if change.To.Name != "" {
+				files[change.To.Name] = true
+			}
+		}
+		commit = parent
+	}
+
+	return files, nil
+}
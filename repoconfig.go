@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Name of the optional top-level file a repo tunes its own scan with.
+const repoConfigFile = ".seekret.yaml"
+
+// repoConfig is the contents of a repo's .seekret.yaml. Rules add checks,
+// Exclude lists path regexps of files not to check, and Severity overrides
+// the severity of rules' findings in the repo. Exclude and Severity only
+// apply as far as repoConfigPolicy allows.
+type repoConfig struct {
+	Rules    []ruleSpec        `yaml:"rules"`
+	Exclude  []string          `yaml:"exclude"`
+	Severity map[string]string `yaml:"severity"`
+
+	rules   []fileRule
+	exclude []*regexp.Regexp
+}
+
+// repoConfigLimits is an org's policy on what repos may change about their
+// own scan. The zero value only lets repos add rules, so no repo can weaken
+// the org baseline without the org opting in.
+type repoConfigLimits struct {
+	// Ignore .seekret.yaml files entirely.
+	Disable bool `json:"disable"`
+	// Honor repos' Exclude lists.
+	AllowExclude bool `json:"allow_exclude"`
+	// Honor repos' Severity overrides.
+	AllowSeverity bool `json:"allow_severity"`
+	// Rules whose findings repos can neither exclude nor re-severity.
+	LockedRules []string `json:"locked_rules"`
+}
+
+// repoConfigPolicy is the policy loaded from --repo-config-policy.
+var repoConfigPolicy repoConfigLimits
+
+// loadRepoConfigPolicy reads a JSON repoConfigLimits, ex.
+// {"allow_exclude": true, "locked_rules": ["aws-access-key-id"]}.
+func loadRepoConfigPolicy(path string) (repoConfigLimits, error) {
+	var limits repoConfigLimits
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return limits, err
+	}
+	err = json.Unmarshal(data, &limits)
+	return limits, err
+}
+
+// parseRepoConfig parses and compiles the .seekret.yaml contents data.
+func parseRepoConfig(data []byte) (*repoConfig, error) {
+	cfg := &repoConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+	var err error
+	if cfg.rules, err = compileRuleSpecs(cfg.Rules); err != nil {
+		return nil, err
+	}
+	for rule, sev := range cfg.Severity {
+		if cfg.Severity[rule], err = parseSeverity(sev); err != nil {
+			return nil, fmt.Errorf("severity of %q: %v", rule, err)
+		}
+	}
+	for _, e := range cfg.Exclude {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("exclude %q: %v", e, err)
+		}
+		cfg.exclude = append(cfg.exclude, re)
+	}
+	return cfg, nil
+}
+
+// excluded reports whether the repo excludes the file at the repo-relative,
+// slash-separated relPath, as allowed by limits.
+func (cfg *repoConfig) excluded(relPath string, limits repoConfigLimits) bool {
+	if !limits.AllowExclude {
+		return false
+	}
+	for _, re := range cfg.exclude {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan returns the positions of the repo's own rule matches in fileData.
+func (cfg *repoConfig) scan(relPath string, fileData []byte) []SensitivePos {
+	normalized, mapping := normalizeText(fileData)
+	return mapping.denormalize(matchFileRules(cfg.rules, relPath, normalized))
+}
+
+// apply overrides pos's severity as the repo requests and limits allow.
+func (cfg *repoConfig) apply(pos *SensitivePos, limits repoConfigLimits) {
+	if sev, ok := cfg.Severity[pos.Rule]; ok && limits.AllowSeverity && !limits.locked(pos.Rule) {
+		pos.Severity = sev
+	}
+}
+
+// locked reports whether repos cannot change how rule's findings are
+// reported.
+func (limits repoConfigLimits) locked(rule string) bool {
+	for _, r := range limits.LockedRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// Kinds of repos reported distinctly, so their results are not mistaken for
+// those of ordinary repos.
+const (
+	// The repo has no commits, so there was nothing to check.
+	repoEmpty = "empty"
+	// The repo is a template, so its files, and findings, are copied into
+	// every repo generated from it.
+	repoTemplate = "template"
+	// Most of the repo's checked files are Git LFS pointers, whose objects
+	// were not fetched, so most of its content is unchecked.
+	repoLFS = "lfs"
+)
+
+// Git LFS pointer files begin with this line, and are smaller than
+// maxLFSPointerSize.
+var lfsPointerPrefix = []byte("version https://git-lfs.github.com/spec/v1\n")
+
+const maxLFSPointerSize = 1024
+
+// templateRepos holds the HTML URLs of repos listed as templates. go-github
+// does not model is_template, so it is recorded here as repos are listed.
+var templateRepos = make(map[string]bool)
+
+// isLFSPointer reports whether fileData is a Git LFS pointer file rather
+// than the file's content.
+func isLFSPointer(fileData []byte) bool {
+	return len(fileData) < maxLFSPointerSize && bytes.HasPrefix(fileData, lfsPointerPrefix)
+}
+
+// isEmptyRepoError reports whether err, from cloning, means the repo has no
+// commits.
+func isEmptyRepoError(err error) bool {
+	return err == transport.ErrEmptyRemoteRepository
+}
+
+// addKind records that sr is of kind, and logs why its results differ from an
+// ordinary repo's.
+func (sr *SensitiveRepo) addKind(kind string) {
+	switch kind {
+	case repoEmpty:
+		logrus.Infof("%s: repo is empty; nothing to check.", sr.Name)
+	case repoTemplate:
+		logrus.Infof("%s: repo is a template; its findings are copied into repos generated from it.", sr.Name)
+	case repoLFS:
+		logrus.Warnf("%s: %d files are Git LFS pointers whose content was not checked.", sr.Name, sr.LFSPointers)
+	}
+	sr.Kinds = append(sr.Kinds, kind)
+}
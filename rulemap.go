@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// ruleMapping assigns organization-specific metadata to a rule's findings.
+type ruleMapping struct {
+	Severity string   `json:"severity"`
+	Tags     []string `json:"tags"`
+	Owner    string   `json:"owner"`
+}
+
+// ruleMappings maps rule names to metadata loaded from --rule-map.
+var ruleMappings map[string]ruleMapping
+
+// loadRuleMappings reads a JSON object mapping rule names to ruleMappings,
+// ex. {"git-credentials": {"severity": "critical", "tags": ["SOX"]}}.
+// Severities are lower-cased, and unknown severities rejected.
+func loadRuleMappings(path string) (map[string]ruleMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]ruleMapping)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for rule, mapping := range m {
+		if mapping.Severity == "" {
+			continue
+		}
+		if mapping.Severity, err = parseSeverity(mapping.Severity); err != nil {
+			return nil, fmt.Errorf("rule %q: %v", rule, err)
+		}
+		m[rule] = mapping
+	}
+	return m, nil
+}
+
+// applyRuleMapping sets pos's severity, tags, and owner from its rule's
+// mapping, if any. Mappings without a severity keep pos's.
+func applyRuleMapping(pos *SensitivePos) {
+	m, ok := ruleMappings[pos.Rule]
+	if !ok {
+		return
+	}
+	if m.Severity != "" {
+		pos.Severity = m.Severity
+	}
+	pos.Tags = m.Tags
+	pos.Owner = m.Owner
+}
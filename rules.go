@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ruleSpec declares a rule in YAML, in a --rules file or a repo's
+// .seekret.yaml. Pattern and Path are regexps; see fileRule for the meaning
+// of each field.
+type ruleSpec struct {
+	Name        string   `yaml:"name"`
+	Pattern     string   `yaml:"pattern"`
+	Path        string   `yaml:"path"`
+	Keywords    []string `yaml:"keywords"`
+	Entropy     float64  `yaml:"entropy"`
+	Severity    string   `yaml:"severity"`
+	Remediation string   `yaml:"remediation"`
+}
+
+// compile returns the fileRule spec declares. Its severity, if any, is
+// lower-cased, and unknown severities rejected.
+func (spec ruleSpec) compile() (fileRule, error) {
+	if spec.Name == "" || spec.Pattern == "" {
+		return fileRule{}, fmt.Errorf("rule %q: name and pattern are required", spec.Name)
+	}
+	rule := fileRule{
+		Name:        spec.Name,
+		Remediation: spec.Remediation,
+		Keywords:    spec.Keywords,
+		MinEntropy:  spec.Entropy,
+	}
+	var err error
+	if spec.Severity != "" {
+		if rule.Severity, err = parseSeverity(spec.Severity); err != nil {
+			return fileRule{}, fmt.Errorf("rule %q: %v", spec.Name, err)
+		}
+	}
+	if rule.Pattern, err = regexp.Compile(spec.Pattern); err != nil {
+		return fileRule{}, fmt.Errorf("rule %q: %v", spec.Name, err)
+	}
+	if spec.Path != "" {
+		if rule.Path, err = regexp.Compile(spec.Path); err != nil {
+			return fileRule{}, fmt.Errorf("rule %q: %v", spec.Name, err)
+		}
+	}
+	return rule, nil
+}
+
+// compileRuleSpecs compiles each of specs.
+func compileRuleSpecs(specs []ruleSpec) ([]fileRule, error) {
+	rules := make([]fileRule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := spec.compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// customRules are the organization's own rules loaded from --rules, applied
+// to every repo alongside the built-in rules.
+var customRules []fileRule
+
+// loadRulesFile reads a YAML file with a top-level list of rules, ex.
+//
+//	rules:
+//	  - name: acme-api-token
+//	    pattern: 'acme_([A-Za-z0-9]{32})'
+//	    keywords: [acme]
+//	    entropy: 3.5
+//	    severity: high
+//	    path: '\.(env|ya?ml)$'
+func loadRulesFile(path string) ([]fileRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []ruleSpec `yaml:"rules"`
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, err
+	}
+	return compileRuleSpecs(file.Rules)
+}
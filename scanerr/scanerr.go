@@ -0,0 +1,165 @@
+// Package scanerr defines the errors scans record for failures that leave
+// part of a repo unchecked, so callers can tell failures apart, retry those
+// likely to be transient, and summarize a scan's errors.
+package scanerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// Kinds of scan errors, one per error type.
+const (
+	KindClone  = "clone"
+	KindAPI    = "api"
+	KindDetect = "detect"
+)
+
+// CloneError is a failure to clone Repo. Retriable is set if the failure is
+// likely transient, as network failures are, so the clone may succeed if
+// tried again.
+type CloneError struct {
+	Repo      string
+	Err       error
+	Retriable bool
+}
+
+func (e *CloneError) Error() string { return fmt.Sprintf("clone %s: %v", e.Repo, e.Err) }
+func (e *CloneError) Unwrap() error { return e.Err }
+
+// APIError is a failed request of Op, ex. "list repos" or "exposure", to
+// GitHub's APIs, on behalf of Repo if it is set. Retriable is set if the
+// failure is likely transient, as rate limits and server errors are.
+type APIError struct {
+	Op        string
+	Repo      string
+	Err       error
+	Retriable bool
+}
+
+func (e *APIError) Error() string {
+	if e.Repo == "" {
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Repo, e.Err)
+}
+func (e *APIError) Unwrap() error { return e.Err }
+
+// DetectError is a failure of Op, ex. "read" or "history", that left Path, or
+// all of Repo if Path is empty, unchecked for sensitive data. Retriable is
+// set if the failure is likely transient.
+type DetectError struct {
+	Op        string
+	Repo      string
+	Path      string
+	Err       error
+	Retriable bool
+}
+
+func (e *DetectError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s %s: %v", e.Op, e.Repo, e.Err)
+	}
+	return fmt.Sprintf("%s %s %s: %v", e.Op, e.Repo, e.Path, e.Err)
+}
+func (e *DetectError) Unwrap() error { return e.Err }
+
+// Retriable reports whether err, or the typed error it wraps, is likely
+// transient, so the operation that failed may succeed if tried again.
+func Retriable(err error) bool {
+	var (
+		cloneErr  *CloneError
+		apiErr    *APIError
+		detectErr *DetectError
+	)
+	switch {
+	case errors.As(err, &cloneErr):
+		return cloneErr.Retriable
+	case errors.As(err, &apiErr):
+		return apiErr.Retriable
+	case errors.As(err, &detectErr):
+		return detectErr.Retriable
+	}
+	return Transient(err)
+}
+
+// Transient reports whether err is a kind of failure that tends to clear up
+// on its own: timeouts, dropped connections, rate limits, and server errors.
+func Transient(err error) bool {
+	var (
+		rateErr  *github.RateLimitError
+		abuseErr *github.AbuseRateLimitError
+		respErr  *github.ErrorResponse
+		netErr   net.Error
+	)
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &rateErr), errors.As(err, &abuseErr):
+		return true
+	case errors.As(err, &respErr):
+		return respErr.Response != nil && respErr.Response.StatusCode >= 500
+	case errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// Kind returns the kind of err: KindClone for a *CloneError, KindAPI for an
+// *APIError, and otherwise KindDetect.
+func Kind(err error) string {
+	var (
+		cloneErr *CloneError
+		apiErr   *APIError
+	)
+	switch {
+	case errors.As(err, &cloneErr):
+		return KindClone
+	case errors.As(err, &apiErr):
+		return KindAPI
+	}
+	return KindDetect
+}
+
+// Summary tallies a scan's errors, in all and by kind, and how many of them
+// are retriable. The zero Summary is empty and ready to use.
+type Summary struct {
+	Total     int            `json:"total"`
+	Retriable int            `json:"retriable"`
+	Kinds     map[string]int `json:"kinds,omitempty"`
+}
+
+// Add counts err in s.
+func (s *Summary) Add(err error) {
+	if s.Kinds == nil {
+		s.Kinds = make(map[string]int)
+	}
+	s.Total++
+	s.Kinds[Kind(err)]++
+	if Retriable(err) {
+		s.Retriable++
+	}
+}
+
+// String returns s as, ex., "3 errors, 1 retriable: 1 clone, 2 detect".
+func (s Summary) String() string {
+	summary := fmt.Sprintf("%d errors, %d retriable", s.Total, s.Retriable)
+	var kinds []string
+	for _, kind := range []string{KindClone, KindAPI, KindDetect} {
+		if n := s.Kinds[kind]; n > 0 {
+			kinds = append(kinds, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	if kinds == nil {
+		return summary
+	}
+	return summary + ": " + strings.Join(kinds, ", ")
+}
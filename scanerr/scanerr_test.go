@@ -0,0 +1,42 @@
+package scanerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRetriable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&CloneError{Repo: "r", Err: errors.New("x"), Retriable: true}, true},
+		{&APIError{Op: "exposure", Err: context.DeadlineExceeded}, false},
+		{fmt.Errorf("wrapped: %w", &DetectError{Op: "read", Err: errors.New("x"), Retriable: true}), true},
+		// Untyped errors are judged by what failed.
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("x"), false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := Retriable(c.err); got != c.want {
+			t.Errorf("Retriable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	if got, want := s.String(), "0 errors, 0 retriable"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	s.Add(&DetectError{Op: "read", Err: errors.New("x")})
+	s.Add(&CloneError{Repo: "r", Err: errors.New("x"), Retriable: true})
+	s.Add(fmt.Errorf("wrapped: %w", &DetectError{Op: "walk", Err: errors.New("x")}))
+	if got, want := s.String(), "3 errors, 1 retriable: 1 clone, 2 detect"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
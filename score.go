@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// Severities, from most to least severe. Findings of rules without a
+// configured severity get their rule's default severity.
+var severityRanks = map[string]int{
+	"critical": 4,
+	"high":     3,
+	"medium":   2,
+	"low":      1,
+}
+
+// Default severities of built-in rules' findings. Other rules' findings are
+// medium.
+var defaultSeverities = map[string]string{
+	"aws-access-key-id":            "high",
+	"aws-secret-access-key":        "high",
+	detection.GitHubToken:          "high",
+	"stripe-secret-key":            "high",
+	"slack-token":                  "high",
+	detection.PrivateKey:           "high",
+	detection.GCPServiceAccountKey: "high",
+	detection.ConnectionString:     "high",
+	detection.URLCredential:        "high",
+	"git-credentials":              "high",
+	detection.HighEntropyBase64:    "low",
+	detection.HighEntropyHex:       "low",
+	detection.KeywordAssignment:    "low",
+}
+
+// How specific each built-in rule is, from 0 to 1: how unlikely a match is
+// to be anything but a secret. Formats with fixed prefixes or structure are
+// most specific, and bare high-entropy strings least. Other rules, which
+// mostly apply to specific files, are 0.75.
+var ruleSpecificity = map[string]float64{
+	"aws-access-key-id":            0.95,
+	detection.GitHubToken:          0.95,
+	"stripe-secret-key":            0.95,
+	"slack-token":                  0.95,
+	"slack-webhook-url":            0.95,
+	detection.PrivateKey:           0.95,
+	detection.GCPServiceAccountKey: 0.95,
+	detection.JWT:                  0.9,
+	detection.ConnectionString:     0.85,
+	detection.URLCredential:        0.85,
+	"aws-secret-access-key":        0.8,
+	"generic-api-key":              0.6,
+	"bearer-token":                 0.6,
+	terraformSecret:                0.6,
+	"generic-password":             0.5,
+	structuredSecret:               0.5,
+	dotenvSecret:                   0.5,
+	dockerfileSecret:               0.5,
+	composeSecret:                  0.5,
+	detection.KeywordAssignment:    0.5,
+	detection.HighEntropyBase64:    0.3,
+	detection.HighEntropyHex:       0.3,
+}
+
+// specificity returns rule's specificity, per ruleSpecificity.
+func specificity(rule string) float64 {
+	if s, ok := ruleSpecificity[rule]; ok {
+		return s
+	}
+	return 0.75
+}
+
+// Entropy, in bits per character, of random base64 text long enough to be a
+// secret. Matches this random or more add the most confidence.
+const randomEntropy = 5.0
+
+// scoreFinding sets pos's confidence, and its rule's default severity if it
+// has none, given the text of the file it was found in. fileData is nil for
+// large files scanned in place.
+func scoreFinding(pos *SensitivePos, fileData []byte) {
+	if pos.Severity == "" {
+		pos.Severity = defaultSeverity(pos.Rule)
+	}
+	var match []byte
+	if fileData != nil {
+		match = fileData[pos.Start:pos.End]
+	}
+	pos.Confidence = confidence(pos, match)
+}
+
+// defaultSeverity returns the severity of rule's findings absent any
+// configuration.
+func defaultSeverity(rule string) string {
+	if sev, ok := defaultSeverities[rule]; ok {
+		return sev
+	}
+	return "medium"
+}
+
+// confidence scores how likely pos, matching match, is a real secret, from 0
+// to 1: its rule's specificity, weighted 0.7, plus the Shannon entropy of
+// match relative to random text's, weighted 0.3. Without match, entropy
+// counts as half random's. Secrets their provider accepted are certain, and
+// ones it rejected are discounted.
+func confidence(pos *SensitivePos, match []byte) float64 {
+	if pos.Verified {
+		return 1
+	}
+	entropy := 0.5
+	if match != nil {
+		entropy = math.Min(detection.ShannonEntropy(match)/randomEntropy, 1)
+	}
+	c := 0.7*specificity(pos.Rule) + 0.3*entropy
+	if pos.Verification == stateInvalid {
+		c *= 0.5
+	}
+	return math.Round(c*100) / 100
+}
+
+// parseSeverity returns severity in lower case, or an error if it is not one
+// of severityRanks.
+func parseSeverity(severity string) (string, error) {
+	s := strings.ToLower(severity)
+	if _, ok := severityRanks[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q, must be critical, high, medium, or low", severity)
+	}
+	return s, nil
+}
+
+// severityAtLeast reports whether severity is min or more severe. Unknown
+// severities, such as those of custom rules, rank below low.
+func severityAtLeast(severity, min string) bool {
+	return severityRanks[severity] >= severityRanks[min]
+}
+
+// Exit status of scans that reported a finding at least as severe as
+// --fail-on.
+const exitFailOn = 3
+
+// failingFound is set once a finding at least as severe as --fail-on has been
+// reported.
+var failingFound bool
+
+// filterFindings returns positions without findings less severe than
+// --min-severity or less confident than --min-confidence, noting whether any
+// is severe enough to fail the scan.
+func filterFindings(positions []SensitivePos) []SensitivePos {
+	kept := positions[:0]
+	for _, pos := range positions {
+		if minSeverity != "" && !severityAtLeast(pos.Severity, minSeverity) || pos.Confidence < minConfidence {
+			continue
+		}
+		if failOn != "" && severityAtLeast(pos.Severity, failOn) {
+			failingFound = true
+		}
+		kept = append(kept, pos)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
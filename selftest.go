@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/estroz/seekret/detection"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+)
+
+var (
+	// Directory of synthetic files scanned as if it were a repo worktree.
+	selftestCorpus string
+	// JSON file mapping each corpus file to the rules expected to flag it.
+	selftestExpected string
+)
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Scan a corpus of synthetic secrets and known false positives and report precision and recall per rule",
+	Run: func(cmd *cobra.Command, args []string) {
+		expected, err := loadSelftestExpected(selftestExpected)
+		if err != nil {
+			logrus.Error("selftest: ", err)
+			os.Exit(1)
+		}
+
+		// Every detector runs, so obfuscation, encoding, entropy, and
+		// keyword cases in the corpus are checked too.
+		deobfuscate = true
+		decodeEncoded = true
+		detection.Register(entropyOpts)
+		detection.Register(keywordOpts)
+
+		sr := SensitiveRepo{Name: "selftest"}
+		scanFS(context.Background(), &sr, osfs.New(selftestCorpus), nil, selftestCorpus, nil, nil)
+		for _, e := range sr.Errors {
+			logrus.Errorf("selftest: %s %s: %s", e.Op, e.Path, e.Error)
+		}
+
+		stats := selftestScore(expected, sr.Files)
+		if err := printSelftest(os.Stdout, stats); err != nil {
+			logrus.Error("selftest: ", err)
+			os.Exit(1)
+		}
+
+		// Any miss or false positive is a regression against the corpus.
+		for _, s := range stats {
+			if s.FalsePositives > 0 || s.FalseNegatives > 0 {
+				os.Exit(1)
+			}
+		}
+		if len(sr.Errors) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	selftestCmd.Flags().StringVar(&selftestCorpus, "corpus", filepath.Join("testdata", "selftest", "corpus"), "Directory of synthetic secrets and known false positives.")
+	selftestCmd.Flags().StringVar(&selftestExpected, "expected", filepath.Join("testdata", "selftest", "expected.json"), "JSON file mapping each corpus file's slash-separated path to the rules expected to flag it. Unlisted files are expected to have no findings.")
+}
+
+// selftestStats counts, for one rule, corpus files the rule correctly
+// flagged, flagged but should not have, and should have flagged but did not.
+type selftestStats struct {
+	Rule           string
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+// Precision is the fraction of files the rule flagged that it should have.
+// A rule that flagged nothing has a precision of 1.
+func (s selftestStats) Precision() float64 {
+	if s.TruePositives+s.FalsePositives == 0 {
+		return 1
+	}
+	return float64(s.TruePositives) / float64(s.TruePositives+s.FalsePositives)
+}
+
+// Recall is the fraction of files the rule should have flagged that it did.
+// A rule expected to flag nothing has a recall of 1.
+func (s selftestStats) Recall() float64 {
+	if s.TruePositives+s.FalseNegatives == 0 {
+		return 1
+	}
+	return float64(s.TruePositives) / float64(s.TruePositives+s.FalseNegatives)
+}
+
+// loadSelftestExpected reads a JSON object mapping corpus file paths to the
+// names of the rules expected to flag them.
+func loadSelftestExpected(path string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var expected map[string][]string
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", path, err)
+	}
+	return expected, nil
+}
+
+// selftestScore compares the rules that flagged each file in files to
+// expected, logging each mismatch, and returns the results per rule sorted by
+// rule name. A rule flagging a file more than once counts once.
+func selftestScore(expected map[string][]string, files []SensitiveFile) []selftestStats {
+	found := make(map[string]map[string]bool)
+	for _, file := range files {
+		path := filepath.ToSlash(file.Path)
+		if found[path] == nil {
+			found[path] = make(map[string]bool)
+		}
+		for _, pos := range file.Positions {
+			found[path][pos.Rule] = true
+		}
+	}
+
+	byRule := make(map[string]*selftestStats)
+	stats := func(rule string) *selftestStats {
+		if byRule[rule] == nil {
+			byRule[rule] = &selftestStats{Rule: rule}
+		}
+		return byRule[rule]
+	}
+	for path, rules := range expected {
+		for _, rule := range rules {
+			if found[path][rule] {
+				stats(rule).TruePositives++
+			} else {
+				stats(rule).FalseNegatives++
+				logrus.Warnf("selftest: %s: missed %s finding", path, rule)
+			}
+		}
+	}
+	for path, rules := range found {
+		want := make(map[string]bool)
+		for _, rule := range expected[path] {
+			want[rule] = true
+		}
+		for rule := range rules {
+			if !want[rule] {
+				stats(rule).FalsePositives++
+				logrus.Warnf("selftest: %s: unexpected %s finding", path, rule)
+			}
+		}
+	}
+
+	results := make([]selftestStats, 0, len(byRule))
+	for _, s := range byRule {
+		results = append(results, *s)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Rule < results[j].Rule })
+	return results
+}
+
+// printSelftest writes a table of stats to w.
+func printSelftest(w io.Writer, stats []selftestStats) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tTP\tFP\tFN\tPRECISION\tRECALL")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f\t%.2f\n",
+			s.Rule, s.TruePositives, s.FalsePositives, s.FalseNegatives, s.Precision(), s.Recall())
+	}
+	return tw.Flush()
+}
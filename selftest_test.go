@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/estroz/seekret/detection"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+)
+
+// TestSelftestCorpus scans the selftest corpus as the selftest command does,
+// and fails on any finding missing from or not listed in expected.json.
+// The entropy and keyword detectors it registers stay registered for the
+// tests run after it.
+func TestSelftestCorpus(t *testing.T) {
+	corpus := filepath.Join("testdata", "selftest", "corpus")
+	expected, err := loadSelftestExpected(filepath.Join("testdata", "selftest", "expected.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(d, e bool) { deobfuscate, decodeEncoded = d, e }(deobfuscate, decodeEncoded)
+	deobfuscate, decodeEncoded = true, true
+	detection.Register(entropyOpts)
+	detection.Register(keywordOpts)
+
+	sr := SensitiveRepo{Name: "selftest"}
+	scanFS(context.Background(), &sr, osfs.New(corpus), nil, corpus, nil, nil)
+	for _, e := range sr.Errors {
+		t.Errorf("%s %s: %s", e.Op, e.Path, e.Error)
+	}
+
+	found := make(map[string]map[string]bool)
+	for _, file := range sr.Files {
+		path := filepath.ToSlash(file.Path)
+		if found[path] == nil {
+			found[path] = make(map[string]bool)
+		}
+		for _, pos := range file.Positions {
+			found[path][pos.Rule] = true
+		}
+	}
+
+	var paths []string
+	for path := range expected {
+		paths = append(paths, path)
+	}
+	for path := range found {
+		if _, ok := expected[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		want := make(map[string]bool)
+		for _, rule := range expected[path] {
+			want[rule] = true
+			if !found[path][rule] {
+				t.Errorf("%s: missing %s finding", path, rule)
+			}
+		}
+		var unexpected []string
+		for rule := range found[path] {
+			if !want[rule] {
+				unexpected = append(unexpected, rule)
+			}
+		}
+		sort.Strings(unexpected)
+		for _, rule := range unexpected {
+			t.Errorf("%s: unexpected %s finding", path, rule)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Slack Web API method tokens are verified with.
+	slackAuthTestURL = "https://slack.com/api/auth.test"
+
+	slackTokenPattern   = regexp.MustCompile(`(xox[abposr]|xoxe\.xox[bp]|xapp)-[0-9A-Za-z-]+`)
+	slackWebhookPattern = regexp.MustCompile(`https://hooks\.slack\.com/\S+`)
+	// Incoming webhooks, the only webhook URLs that can be checked without
+	// running anything. Workflow and trigger URLs start workflows in their
+	// workspace when posted to.
+	slackIncomingWebhookPattern = regexp.MustCompile(`^https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]+`)
+)
+
+// errSlackWorkflowWebhook is returned for Slack webhook URLs other than
+// incoming webhooks, which cannot be checked without running workflows.
+var errSlackWorkflowWebhook = errors.New("only incoming webhooks can be verified")
+
+// slackTokenVerifier checks Slack tokens with auth.test.
+type slackTokenVerifier struct{}
+
+func (slackTokenVerifier) Provider() string { return "slack" }
+
+func (slackTokenVerifier) Verify(ctx context.Context, match []byte) (bool, error) {
+	token := slackTokenPattern.Find(match)
+	if token == nil {
+		return false, errNoSecret
+	}
+	return slackTokenActive(ctx, string(token))
+}
+
+// slackWebhookVerifier checks Slack incoming webhook URLs by posting an empty
+// payload, which Slack rejects without posting anything. Other webhook URLs
+// are left unverified.
+type slackWebhookVerifier struct{}
+
+func (slackWebhookVerifier) Provider() string { return "slack" }
+
+func (slackWebhookVerifier) Verify(ctx context.Context, match []byte) (bool, error) {
+	url := slackWebhookPattern.Find(match)
+	if url == nil {
+		return false, errNoSecret
+	}
+	incoming := slackIncomingWebhookPattern.Find(url)
+	if incoming == nil {
+		return false, errSlackWorkflowWebhook
+	}
+	return slackWebhookActive(ctx, string(incoming))
+}
+
+// slackTokenActive calls auth.test with token and reports whether Slack
+// accepted it.
+func slackTokenActive(ctx context.Context, token string) (bool, error) {
+	req, err := http.NewRequest("POST", slackAuthTestURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("slack: unexpected status %s", resp.Status)
+	}
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return result.OK, nil
+}
+
+// slackWebhookActive posts an empty payload to url, an incoming webhook, and
+// reports whether Slack recognized the webhook, answering that the payload is
+// invalid rather than that the webhook does not exist. An empty payload holds
+// no message, so nothing can be posted.
+func slackWebhookActive(ctx context.Context, url string) (bool, error) {
+	req, err := http.NewRequest("POST", url, strings.NewReader(""))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return strings.Contains(string(body), "no_text") || strings.Contains(string(body), "invalid_payload"), nil
+	case resp.StatusCode == http.StatusForbidden, resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		// Revoked webhooks and deleted channels and apps.
+		return false, nil
+	}
+	return false, fmt.Errorf("slack: unexpected status %s", resp.Status)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Directory spill files are created in, or the default temp directory if
+// empty. CrawlRepos sets it to its own temp directory, so spill files do not
+// outlive the crawl.
+var spillDir string
+
+// findingSpill holds files of findings past spillThreshold as JSON lines in a
+// temp file, accessible only by the current user, so huge result sets need
+// not fit in memory until they are written.
+type findingSpill struct {
+	f     *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	files int
+}
+
+func newFindingSpill() (*findingSpill, error) {
+	// TempFile creates files with mode 0600.
+	f, err := ioutil.TempFile(spillDir, "spill_")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &findingSpill{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// add appends file to s.
+func (s *findingSpill) add(file SensitiveFile) error {
+	if err := s.enc.Encode(file); err != nil {
+		return err
+	}
+	s.files++
+	return nil
+}
+
+// each calls fn with each file of s in the order they were added, reading
+// them back one at a time, and stops at the first error.
+func (s *findingSpill) each(fn func(SensitiveFile) error) error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(io.NewSectionReader(s.f, 0, info.Size()))
+	for {
+		var file SensitiveFile
+		if err := dec.Decode(&file); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(file); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes and removes s's file.
+func (s *findingSpill) Close() error {
+	err := s.f.Close()
+	if rmErr := os.Remove(s.f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// spillEnabled reports whether findings may be spilled to disk. Memory-only
+// scans write nothing to disk, and secret age, exposure checks, and
+// quarantine act on all of a repo's findings in memory once it is scanned.
+func spillEnabled() bool {
+	return spillThreshold > 0 && !inMemory && !secretAge && !checkExposure && !quarantine
+}
+
+// addFile adds file to sr's files, spilling it to disk if sr already holds
+// spillThreshold findings in memory and spilling is enabled. If the spill
+// file cannot be written, the error is recorded once and files are kept in
+// memory.
+func (sr *SensitiveRepo) addFile(file SensitiveFile) {
+	if spillEnabled() && !sr.noSpill && sr.inMemory >= spillThreshold {
+		if sr.spill == nil {
+			spill, err := newFindingSpill()
+			if err != nil {
+				sr.addError("spill", "", err)
+				sr.noSpill = true
+			}
+			sr.spill = spill
+		}
+		if sr.spill != nil {
+			err := sr.spill.add(file)
+			if err == nil {
+				return
+			}
+			sr.addError("spill", file.Path, err)
+			sr.noSpill = true
+		}
+	}
+	sr.inMemory += len(file.Positions)
+	sr.Files = append(sr.Files, file)
+}
+
+// eachFile calls fn with each of sr's files, those in Files and then those
+// spilled to disk, and stops at the first error.
+func (sr SensitiveRepo) eachFile(fn func(SensitiveFile) error) error {
+	for _, file := range sr.Files {
+		if err := fn(file); err != nil {
+			return err
+		}
+	}
+	if sr.spill == nil {
+		return nil
+	}
+	return sr.spill.each(fn)
+}
+
+// closeSpill removes sr's spilled files, if any. sr must not be written
+// after.
+func (sr *SensitiveRepo) closeSpill() {
+	if sr.spill == nil {
+		return
+	}
+	if err := sr.spill.Close(); err != nil {
+		logrus.Error("closeSpill: ", err)
+	}
+	sr.spill = nil
+}
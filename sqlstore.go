@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	// Drivers for the sqlite3 and postgres stores.
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqlSchema is valid for both SQLite and Postgres.
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS findings (
+		id TEXT PRIMARY KEY,
+		repo TEXT NOT NULL,
+		path TEXT NOT NULL,
+		commit_sha TEXT NOT NULL,
+		rule TEXT NOT NULL,
+		start_offset INTEGER NOT NULL,
+		end_offset INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		first_seen TIMESTAMP NOT NULL,
+		last_seen TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS scans (
+		owner TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP NOT NULL,
+		repos INTEGER NOT NULL,
+		findings INTEGER NOT NULL,
+		errors INTEGER NOT NULL
+	)`,
+}
+
+// sqlStore is a Store backed by a SQLite or Postgres database.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// openSQLStore connects to the database dsn with driver, creating tables if
+// needed.
+func openSQLStore(driver, dsn string) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &sqlStore{db: db, driver: driver}
+	for _, stmt := range sqlSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("create %s schema: %v", driver, err)
+		}
+	}
+	return s, nil
+}
+
+// rebind replaces the '?' placeholders in query with the driver's.
+func (s *sqlStore) rebind(query string) string {
+	if s.driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *sqlStore) SaveFinding(f StoredFinding) error {
+	_, err := s.db.Exec(s.rebind(`INSERT INTO findings
+		(id, repo, path, commit_sha, rule, start_offset, end_offset, status, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET last_seen = excluded.last_seen`),
+		f.ID, f.Repo, f.Path, f.Commit, f.Rule, f.Start, f.End, f.Status, f.FirstSeen, f.LastSeen)
+	return err
+}
+
+func (s *sqlStore) UpdateStatus(id, status string) error {
+	res, err := s.db.Exec(s.rebind(`UPDATE findings SET status = ? WHERE id = ?`), status, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("finding %s not found", id)
+	}
+	return nil
+}
+
+func (s *sqlStore) QueryFindings(q FindingQuery) ([]StoredFinding, error) {
+	query := `SELECT id, repo, path, commit_sha, rule, start_offset, end_offset, status, first_seen, last_seen
+		FROM findings WHERE 1 = 1`
+	var args []interface{}
+	for _, c := range []struct{ col, val string }{{"repo", q.Repo}, {"rule", q.Rule}, {"status", q.Status}} {
+		if c.val != "" {
+			query += " AND " + c.col + " = ?"
+			args = append(args, c.val)
+		}
+	}
+	query += " ORDER BY id"
+
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fs []StoredFinding
+	for rows.Next() {
+		var f StoredFinding
+		if err := rows.Scan(&f.ID, &f.Repo, &f.Path, &f.Commit, &f.Rule, &f.Start, &f.End, &f.Status, &f.FirstSeen, &f.LastSeen); err != nil {
+			return nil, err
+		}
+		fs = append(fs, f)
+	}
+	return fs, rows.Err()
+}
+
+func (s *sqlStore) SaveScan(r ScanRecord) error {
+	_, err := s.db.Exec(s.rebind(`INSERT INTO scans
+		(owner, started_at, finished_at, repos, findings, errors)
+		VALUES (?, ?, ?, ?, ?, ?)`),
+		r.Owner, r.StartedAt, r.FinishedAt, r.Repos, r.Findings, r.Errors)
+	return err
+}
+
+func (s *sqlStore) QueryScans(owner string) ([]ScanRecord, error) {
+	query := `SELECT owner, started_at, finished_at, repos, findings, errors FROM scans`
+	var args []interface{}
+	if owner != "" {
+		query += " WHERE owner = ?"
+		args = append(args, owner)
+	}
+	query += " ORDER BY started_at"
+
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rs []ScanRecord
+	for rows.Next() {
+		var r ScanRecord
+		if err := rows.Scan(&r.Owner, &r.StartedAt, &r.FinishedAt, &r.Repos, &r.Findings, &r.Errors); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finding statuses.
+const (
+	statusOpen     = "open"
+	statusResolved = "resolved"
+	statusIgnored  = "ignored"
+)
+
+// StoredFinding is a finding tracked across scans. It holds no matched data.
+// Repo is the repo's full name, owner/name.
+type StoredFinding struct {
+	ID        string    `json:"id"`
+	Repo      string    `json:"repo"`
+	Path      string    `json:"path"`
+	Commit    string    `json:"commit,omitempty"`
+	Rule      string    `json:"rule"`
+	Start     int       `json:"start"`
+	End       int       `json:"end"`
+	Status    string    `json:"status"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ScanRecord summarizes one scan of an owner's repos. Repos counts only
+// repos with findings or errors.
+type ScanRecord struct {
+	Owner      string    `json:"owner"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Repos      int       `json:"repos"`
+	Findings   int       `json:"findings"`
+	Errors     int       `json:"errors"`
+}
+
+// FindingQuery selects stored findings. Empty fields match everything.
+type FindingQuery struct {
+	Repo   string
+	Rule   string
+	Status string
+}
+
+// Store persists findings and scans so results can be compared over time.
+type Store interface {
+	// SaveFinding adds f, or if a finding with f's ID exists, updates its
+	// LastSeen. An existing finding keeps its FirstSeen and status.
+	SaveFinding(f StoredFinding) error
+	// UpdateStatus sets the status of the finding with ID id.
+	UpdateStatus(id, status string) error
+	// QueryFindings returns findings matching q ordered by ID.
+	QueryFindings(q FindingQuery) ([]StoredFinding, error)
+	// SaveScan records a completed scan.
+	SaveScan(s ScanRecord) error
+	// QueryScans returns the scans of owner, or all scans if owner is
+	// empty, ordered by start time.
+	QueryScans(owner string) ([]ScanRecord, error)
+	Close() error
+}
+
+// findingID identifies a finding by where it is and which rule matched, so
+// rescanning an unchanged file yields the same IDs.
+func findingID(repo, path, commit, rule string, start int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%d", repo, path, commit, rule, start)))
+	return hex.EncodeToString(sum[:])
+}
+
+// openStore opens the store described by spec: "memory", "sqlite:<path>", or
+// a postgres:// connection URL.
+func openStore(spec string) (Store, error) {
+	switch {
+	case spec == "memory":
+		return newMemoryStore(), nil
+	case strings.HasPrefix(spec, "sqlite:"):
+		return openSQLStore("sqlite3", strings.TrimPrefix(spec, "sqlite:"))
+	case strings.HasPrefix(spec, "postgres://"), strings.HasPrefix(spec, "postgresql://"):
+		return openSQLStore("postgres", spec)
+	}
+	return nil, fmt.Errorf("unknown store %q: want memory, sqlite:<path>, or postgres://...", spec)
+}
+
+// memoryStore is a Store that lasts only as long as the process.
+type memoryStore struct {
+	mu       sync.Mutex
+	findings map[string]StoredFinding
+	scans    []ScanRecord
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{findings: make(map[string]StoredFinding)}
+}
+
+func (s *memoryStore) SaveFinding(f StoredFinding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.findings[f.ID]; ok {
+		old.LastSeen = f.LastSeen
+		s.findings[f.ID] = old
+		return nil
+	}
+	s.findings[f.ID] = f
+	return nil
+}
+
+func (s *memoryStore) UpdateStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.findings[id]
+	if !ok {
+		return fmt.Errorf("finding %s not found", id)
+	}
+	f.Status = status
+	s.findings[id] = f
+	return nil
+}
+
+func (s *memoryStore) QueryFindings(q FindingQuery) ([]StoredFinding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fs []StoredFinding
+	for _, f := range s.findings {
+		if (q.Repo == "" || q.Repo == f.Repo) &&
+			(q.Rule == "" || q.Rule == f.Rule) &&
+			(q.Status == "" || q.Status == f.Status) {
+			fs = append(fs, f)
+		}
+	}
+	sort.Slice(fs, func(i, j int) bool { return fs[i].ID < fs[j].ID })
+	return fs, nil
+}
+
+func (s *memoryStore) SaveScan(r ScanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scans = append(s.scans, r)
+	return nil
+}
+
+func (s *memoryStore) QueryScans(owner string) ([]ScanRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rs []ScanRecord
+	for _, r := range s.scans {
+		if owner == "" || owner == r.Owner {
+			rs = append(rs, r)
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].StartedAt.Before(rs[j].StartedAt) })
+	return rs, nil
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+// storeWriter is a ResultWriter saving each repo's findings to a Store and,
+// on Close, a record of the scan.
+type storeWriter struct {
+	store Store
+	scan  ScanRecord
+}
+
+func newStoreWriter(store Store, owner string) *storeWriter {
+	return &storeWriter{store: store, scan: ScanRecord{Owner: owner, StartedAt: time.Now().UTC()}}
+}
+
+func (w *storeWriter) WriteRepo(sr SensitiveRepo) error {
+	now := time.Now().UTC()
+	w.scan.Repos++
+	w.scan.Errors += len(sr.Errors)
+	// Repos outside the scanned owner are already named by their full name.
+	repo := sr.Name
+	if !strings.Contains(repo, "/") {
+		repo = w.scan.Owner + "/" + repo
+	}
+	return sr.eachFile(func(file SensitiveFile) error {
+		for _, pos := range file.Positions {
+			w.scan.Findings++
+			f := StoredFinding{
+				ID:        findingID(repo, file.Path, file.Commit, pos.Rule, pos.Start),
+				Repo:      repo,
+				Path:      file.Path,
+				Commit:    file.Commit,
+				Rule:      pos.Rule,
+				Start:     pos.Start,
+				End:       pos.End,
+				Status:    statusOpen,
+				FirstSeen: now,
+				LastSeen:  now,
+			}
+			if err := w.store.SaveFinding(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close saves the scan record. It does not close the store.
+func (w *storeWriter) Close() error {
+	w.scan.FinishedAt = time.Now().UTC()
+	return w.store.SaveScan(w.scan)
+}
+
+// multiResultWriter writes results to each of its ResultWriters in order,
+// stopping at the first error.
+type multiResultWriter []ResultWriter
+
+func (ws multiResultWriter) WriteRepo(sr SensitiveRepo) error {
+	for _, w := range ws {
+		if err := w.WriteRepo(sr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// Rule of values of secret-named keys in JSON and YAML files.
+const structuredSecret = "structured-secret"
+
+// Shortest value of a secret-named key flagged as a secret.
+const minStructuredSecret = 8
+
+// structValue is a scalar value in a JSON or YAML file: its key path, ex.
+// spec.containers[0].env[2].value, the last key on that path, its text, and
+// the bytes Start to End the text spans, less quotes. Doc numbers the
+// document holding the value, in files of several YAML documents or JSON
+// values.
+type structValue struct {
+	Path  string
+	Key   string
+	Value string
+	Start int
+	End   int
+	Doc   int
+}
+
+// pathFrame is an object or array a value is nested in: an object's current
+// key, or an array's current index. For YAML, Indent is the column of the
+// object's keys or the array's dashes. For JSON, WantKey is set while the
+// next token of an object is a key.
+type pathFrame struct {
+	Array   bool
+	Index   int
+	Key     string
+	Indent  int
+	WantKey bool
+}
+
+// keyPath returns the key path of a value nested in stack.
+func keyPath(stack []pathFrame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		if f.Array {
+			b.WriteString("[" + strconv.Itoa(f.Index) + "]")
+			continue
+		}
+		if b.Len() != 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(f.Key)
+	}
+	return b.String()
+}
+
+// lastKey returns the innermost object key of stack.
+func lastKey(stack []pathFrame) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if !stack[i].Array {
+			return stack[i].Key
+		}
+	}
+	return ""
+}
+
+// findStructured sets KeyPath on each of positions in values, those of the
+// JSON, YAML, or dotenv file at the repo-relative, slash-separated relPath,
+// and returns the positions of values of secret-named keys, or of name/value
+// pairs with secret names, not already covered by positions. Values that
+// reference a secret, such as ${DB_PASSWORD}, are not flagged. Dotenv files
+// exist to hold secrets, so any non-empty value of theirs is flagged under
+// dotenvSecret; other files' values must look like a single token.
+func findStructured(relPath string, values []structValue, positions []SensitivePos) (found []SensitivePos) {
+	if len(values) == 0 {
+		return nil
+	}
+	dotenv := isDotenv(relPath)
+	for i := range positions {
+		if v := valueAt(values, positions[i].Start); v != nil {
+			positions[i].KeyPath = v.Path
+		}
+	}
+
+	// Values of name/value pairs, as in Kubernetes env entries, are named by
+	// their name.
+	names := make(map[string]string)
+	for _, v := range values {
+		if v.Key == "name" {
+			names[strings.TrimSuffix(v.Path, "name")] = v.Value
+		}
+	}
+	for _, v := range values {
+		key := v.Key
+		if key == "value" {
+			key = names[strings.TrimSuffix(v.Path, "value")]
+		}
+		if v.Value == "" || !detection.SecretKey(key) || detection.SecretReference(v.Value) || overlapsAny(positions, v.Start, v.End) {
+			continue
+		}
+		pos := SensitivePos{
+			Start:       v.Start,
+			End:         v.End,
+			KeyPath:     v.Path,
+			Rule:        structuredSecret,
+			Remediation: "Revoke or change the credential, and have the file reference it from the environment or a secrets manager instead.",
+		}
+		if dotenv {
+			pos.Rule = dotenvSecret
+			pos.Remediation = "Revoke or change the credential, remove the file from the repo and its history, and commit a template with empty values instead."
+		} else if len(v.Value) < minStructuredSecret || strings.ContainsAny(v.Value, " \t\r\n") {
+			continue
+		}
+		found = append(found, pos)
+	}
+	return found
+}
+
+// structValues returns the scalar values of fileData, a file at relPath, if
+// it is JSON, YAML, dotenv, Terraform state, Terraform variables, or a dev
+// container config. Files with a .json extension that fail to
+// parse are treated as plain text.
+func structValues(relPath string, fileData []byte) []structValue {
+	switch {
+	case isDotenv(relPath):
+		return dotenvValues(fileData)
+	case isTerraformState(relPath):
+		values, err := jsonValues(fileData)
+		if err != nil {
+			return nil
+		}
+		return values
+	case isDevcontainer(relPath):
+		values, err := jsonValues(jsoncData(fileData))
+		if err != nil {
+			return nil
+		}
+		return values
+	case strings.HasSuffix(relPath, ".tfvars"):
+		return tfvarsValues(fileData)
+	}
+	switch strings.ToLower(path.Ext(relPath)) {
+	case ".json":
+		values, err := jsonValues(fileData)
+		if err != nil {
+			return nil
+		}
+		return values
+	case ".yaml", ".yml":
+		return yamlValues(fileData)
+	}
+	return nil
+}
+
+// valueAt returns the value of values, sorted by Start, spanning offset, or
+// nil if none does.
+func valueAt(values []structValue, offset int) *structValue {
+	i := sort.Search(len(values), func(i int) bool { return values[i].End > offset })
+	if i < len(values) && values[i].Start <= offset {
+		return &values[i]
+	}
+	return nil
+}
+
+// jsonValues returns the string values of the JSON values in data.
+func jsonValues(data []byte) ([]structValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var (
+		stack  []pathFrame
+		values []structValue
+		end    int
+		doc    = -1
+	)
+	// valueDone readies the innermost object, if any, for its next key.
+	valueDone := func() {
+		if n := len(stack); n != 0 && !stack[n-1].Array {
+			stack[n-1].WantKey = true
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Tokens are preceded by whitespace and the separators Token skips.
+		start := end
+		end = int(dec.InputOffset())
+		for start < end && strings.IndexByte(" \t\r\n:,", data[start]) >= 0 {
+			start++
+		}
+
+		if len(stack) == 0 {
+			doc++
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+		if n := len(stack); n != 0 {
+			top := &stack[n-1]
+			if top.WantKey {
+				top.Key, _ = tok.(string)
+				top.WantKey = false
+				continue
+			}
+			if top.Array {
+				top.Index++
+			}
+		}
+		switch tok := tok.(type) {
+		case json.Delim:
+			stack = append(stack, pathFrame{Array: tok == '[', Index: -1, WantKey: tok == '{'})
+			continue
+		case string:
+			values = append(values, structValue{
+				Path:  keyPath(stack),
+				Key:   lastKey(stack),
+				Value: tok,
+				Start: start + 1,
+				End:   end - 1,
+				Doc:   doc,
+			})
+		}
+		valueDone()
+	}
+}
+
+// yamlValues returns the scalar values of the block-style YAML documents in
+// data. Flow-style collections, ex. [a, b], are not descended into.
+func yamlValues(data []byte) []structValue {
+	var (
+		stack  []pathFrame
+		values []structValue
+		doc    int
+		// A block scalar, ex. key: |, being read, and the column its
+		// lines are indented past.
+		block       *structValue
+		blockIndent int
+	)
+	for off := 0; off < len(data); {
+		lineEnd := bytes.IndexByte(data[off:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(data)
+		} else {
+			lineEnd += off
+		}
+		line := bytes.TrimRight(data[off:lineEnd], " \t\r")
+		lineStart := off
+		off = lineEnd + 1
+
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		content := string(line[indent:])
+		if block != nil {
+			if content == "" || indent > blockIndent {
+				if content != "" {
+					if block.Start < 0 {
+						block.Start = lineStart + indent
+					}
+					block.End = lineStart + len(line)
+				}
+				continue
+			}
+			if block.Start >= 0 {
+				block.Value = string(data[block.Start:block.End])
+				values = append(values, *block)
+			}
+			block = nil
+		}
+		if content == "" || content[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(content, "---") || strings.HasPrefix(content, "...") {
+			stack = nil
+			doc++
+			continue
+		}
+
+		// Sequence items, possibly several on one line as in - - a.
+		col, dashCol, item := indent, 0, false
+		for content == "-" || strings.HasPrefix(content, "- ") {
+			for len(stack) != 0 && stack[len(stack)-1].Indent > col {
+				stack = stack[:len(stack)-1]
+			}
+			if n := len(stack); n != 0 && stack[n-1].Array && stack[n-1].Indent == col {
+				stack[n-1].Index++
+			} else {
+				stack = append(stack, pathFrame{Array: true, Indent: col})
+			}
+			n := 1 + len(content[1:]) - len(strings.TrimLeft(content[1:], " "))
+			dashCol = col
+			col += n
+			content = content[n:]
+			item = true
+		}
+		if content == "" {
+			continue
+		}
+
+		// Block scalars' lines are indented past the key or dash they are
+		// the value of.
+		valueCol, parentCol := col, dashCol
+		if key, keyLen, ok := splitYAMLKey(content); ok {
+			// Sequences may be indented as far as the key they are the
+			// value of, so an array at a key's column is closed by the key.
+			for len(stack) != 0 {
+				top := stack[len(stack)-1]
+				if top.Indent < col || top.Indent == col && !top.Array {
+					break
+				}
+				stack = stack[:len(stack)-1]
+			}
+			if n := len(stack); n != 0 && !stack[n-1].Array && stack[n-1].Indent == col {
+				stack[n-1].Key = key
+			} else {
+				stack = append(stack, pathFrame{Key: key, Indent: col})
+			}
+			content = content[keyLen:]
+			valueCol += keyLen
+			parentCol = col
+		} else if !item {
+			// Continuations of multi-line plain scalars.
+			continue
+		}
+
+		v, vStart, vEnd := yamlScalar(content)
+		switch {
+		case v == "":
+		case v[0] == '|' || v[0] == '>':
+			block = &structValue{Path: keyPath(stack), Key: lastKey(stack), Start: -1, Doc: doc}
+			blockIndent = parentCol
+		default:
+			values = append(values, structValue{
+				Path:  keyPath(stack),
+				Key:   lastKey(stack),
+				Value: v,
+				Start: lineStart + valueCol + vStart,
+				End:   lineStart + valueCol + vEnd,
+				Doc:   doc,
+			})
+		}
+	}
+	if block != nil && block.Start >= 0 {
+		block.Value = string(data[block.Start:block.End])
+		values = append(values, *block)
+	}
+	return values
+}
+
+// splitYAMLKey returns the key of content if it is a block mapping entry, as
+// in key: value, and the offset of its value.
+func splitYAMLKey(content string) (key string, valueOffset int, ok bool) {
+	var i int
+	switch content[0] {
+	case '"', '\'':
+		q := strings.IndexByte(content[1:], content[0])
+		if q < 0 {
+			return "", 0, false
+		}
+		key, i = content[1:q+1], q+2
+		if i >= len(content) || content[i] != ':' {
+			return "", 0, false
+		}
+	case '{', '[', '&', '*', '!', '|', '>', '#', '%', '@', '`':
+		return "", 0, false
+	default:
+		i = strings.Index(content, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(content, ":") {
+				return "", 0, false
+			}
+			i = len(content) - 1
+		}
+		key = strings.TrimSpace(content[:i])
+		if strings.Contains(key, " #") {
+			return "", 0, false
+		}
+	}
+	i++
+	for i < len(content) && content[i] == ' ' {
+		i++
+	}
+	return key, i, true
+}
+
+// yamlScalar returns the scalar content, the value of a key or sequence item,
+// holds, less anchors, tags, quotes, and trailing comments, and its offsets in
+// content. Aliases and flow collections hold no scalar.
+func yamlScalar(content string) (v string, start, end int) {
+	// Anchors and tags precede the value.
+	for start < len(content) && (content[start] == '&' || content[start] == '!') {
+		sp := strings.IndexByte(content[start:], ' ')
+		if sp < 0 {
+			return "", 0, 0
+		}
+		start += sp + 1
+		for start < len(content) && content[start] == ' ' {
+			start++
+		}
+	}
+	if start == len(content) {
+		return "", 0, 0
+	}
+	switch content[start] {
+	case '*', '{', '[':
+		return "", 0, 0
+	case '"', '\'':
+		q := strings.IndexByte(content[start+1:], content[start])
+		if q < 0 {
+			return "", 0, 0
+		}
+		return content[start+1 : start+1+q], start + 1, start + 1 + q
+	}
+	end = len(content)
+	if c := strings.Index(content[start:], " #"); c >= 0 {
+		end = start + c
+	}
+	v = strings.TrimRight(content[start:end], " \t")
+	return v, start, start + len(v)
+}
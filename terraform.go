@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/estroz/seekret/detection"
+)
+
+// Rule of sensitive values in Terraform state and variable files.
+const terraformSecret = "terraform-secret"
+
+var (
+	// Key paths of resource instances' attributes in state, and of outputs'
+	// values. Submatches are the resource and instance indices and the
+	// attribute path, or the output name.
+	tfAttributePath = regexp.MustCompile(`^resources\[(\d+)\]\.instances\[(\d+)\]\.attributes\.(.+)$`)
+	tfOutputPath    = regexp.MustCompile(`^outputs\.([^.\[]+)\.value`)
+)
+
+// tfValue is a value in a Terraform state or variable file, with the address
+// of what it belongs to: a resource instance, ex.
+// module.db.aws_db_instance.main[0], output.<name>, or var.<name>. Sensitive
+// is set if Terraform marks the value sensitive or it has a secret name.
+type tfValue struct {
+	structValue
+	Address   string
+	Sensitive bool
+}
+
+// tfState is the part of a Terraform state file, version 4, addresses are
+// made from.
+type tfState struct {
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey            interface{}    `json:"index_key"`
+			SensitiveAttributes [][]tfPathStep `json:"sensitive_attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+	Outputs map[string]struct {
+		Sensitive bool `json:"sensitive"`
+	} `json:"outputs"`
+}
+
+// tfPathStep is a step of the path of a sensitive attribute: an attribute
+// name, or a map key or list index.
+type tfPathStep struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// isTerraformState reports whether the file at the slash-separated relPath
+// is Terraform state, including the backup Terraform keeps of the last state.
+func isTerraformState(relPath string) bool {
+	base := path.Base(relPath)
+	return strings.HasSuffix(base, ".tfstate") || strings.HasSuffix(base, ".tfstate.backup")
+}
+
+// isTerraformVars reports whether the file at the slash-separated relPath
+// sets Terraform variables, in HCL or JSON.
+func isTerraformVars(relPath string) bool {
+	return strings.HasSuffix(relPath, ".tfvars") || strings.HasSuffix(relPath, ".tfvars.json")
+}
+
+// terraformValues returns values, those of fileData, a file at relPath, with
+// their addresses if the file is Terraform state or variables.
+func terraformValues(relPath string, fileData []byte, values []structValue) (tf []tfValue) {
+	switch {
+	case isTerraformVars(relPath):
+		for _, v := range values {
+			name := v.Path
+			if i := strings.IndexAny(name, ".["); i >= 0 {
+				name = name[:i]
+			}
+			tf = append(tf, tfValue{structValue: v, Address: "var." + name, Sensitive: detection.SecretKey(v.Key)})
+		}
+	case isTerraformState(relPath):
+		var state tfState
+		if err := json.Unmarshal(fileData, &state); err != nil {
+			return nil
+		}
+		for _, v := range values {
+			if m := tfOutputPath.FindStringSubmatch(v.Path); m != nil {
+				tf = append(tf, tfValue{structValue: v, Address: "output." + m[1],
+					Sensitive: state.Outputs[m[1]].Sensitive || detection.SecretKey(m[1])})
+				continue
+			}
+			m := tfAttributePath.FindStringSubmatch(v.Path)
+			if m == nil {
+				continue
+			}
+			r, _ := strconv.Atoi(m[1])
+			i, _ := strconv.Atoi(m[2])
+			if r >= len(state.Resources) || i >= len(state.Resources[r].Instances) {
+				continue
+			}
+			res, inst := state.Resources[r], state.Resources[r].Instances[i]
+			address := res.Type + "." + res.Name
+			if res.Mode == "data" {
+				address = "data." + address
+			}
+			if res.Module != "" {
+				address = res.Module + "." + address
+			}
+			switch key := inst.IndexKey.(type) {
+			case float64:
+				address += "[" + strconv.FormatFloat(key, 'f', -1, 64) + "]"
+			case string:
+				address += "[" + strconv.Quote(key) + "]"
+			}
+			sensitive := detection.SecretKey(v.Key)
+			for _, steps := range inst.SensitiveAttributes {
+				if p := tfAttribute(steps); p != "" && (m[3] == p || strings.HasPrefix(m[3], p+".") || strings.HasPrefix(m[3], p+"[")) {
+					sensitive = true
+				}
+			}
+			tf = append(tf, tfValue{structValue: v, Address: address, Sensitive: sensitive})
+		}
+	}
+	return tf
+}
+
+// tfAttribute returns steps as a key path, ex. connection.password or
+// tags.secret, or "" if a step is of an unknown type.
+func tfAttribute(steps []tfPathStep) string {
+	var b strings.Builder
+	for _, s := range steps {
+		switch v := s.Value.(type) {
+		case string:
+			if b.Len() != 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(v)
+		case float64:
+			fmt.Fprintf(&b, "[%d]", int(v))
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// findTerraform returns the positions of sensitive values of tf, except those
+// referencing a secret or already covered by positions.
+func findTerraform(tf []tfValue, positions []SensitivePos) (found []SensitivePos) {
+	for _, v := range tf {
+		if !v.Sensitive || v.Value == "" || detection.SecretReference(v.Value) || overlapsAny(positions, v.Start, v.End) {
+			continue
+		}
+		found = append(found, SensitivePos{
+			Start:       v.Start,
+			End:         v.End,
+			KeyPath:     v.Path,
+			Rule:        terraformSecret,
+			Remediation: "Revoke or change the credential. Keep state in an encrypted remote backend rather than the repo, and pass secret variables through the environment, ex. TF_VAR_<name>.",
+			Details:     map[string]string{"address": v.Address},
+		})
+	}
+	return found
+}
+
+// labelTerraform adds the address of the value of tf each of positions
+// overlaps, if any, to its Details as address. Matches of assignments, which
+// start at the key, are labeled too.
+func labelTerraform(tf []tfValue, positions []SensitivePos) {
+	for _, v := range tf {
+		for i := range positions {
+			pos := &positions[i]
+			if !overlapsAny(positions[i:i+1], v.Start, v.End) {
+				continue
+			}
+			if pos.Details == nil {
+				pos.Details = map[string]string{}
+			}
+			pos.Details["address"] = v.Address
+		}
+	}
+}
+
+// overlapsAny reports whether any of positions overlaps bytes start to end.
+func overlapsAny(positions []SensitivePos, start, end int) bool {
+	for _, p := range positions {
+		if p.Start < end && start < p.End {
+			return true
+		}
+	}
+	return false
+}
+
+// tfvarsValues returns the quoted string values assigned in data, a .tfvars
+// file in HCL. Values in objects, ex. tags = { owner = "..." }, are nested
+// under their keys; values in lists and heredocs are skipped.
+func tfvarsValues(data []byte) []structValue {
+	var (
+		stack     []pathFrame
+		values    []structValue
+		heredoc   string
+		listDepth int
+	)
+	for off := 0; off < len(data); {
+		lineEnd := bytes.IndexByte(data[off:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(data)
+		} else {
+			lineEnd += off
+		}
+		lineStart := off
+		off = lineEnd + 1
+
+		line := string(bytes.TrimRight(data[lineStart:lineEnd], " \t\r"))
+		content := strings.TrimLeft(line, " \t")
+		switch {
+		case heredoc != "":
+			if content == heredoc {
+				heredoc = ""
+			}
+			continue
+		case listDepth > 0:
+			listDepth += strings.Count(content, "[") - strings.Count(content, "]")
+			continue
+		case content == "" || content[0] == '#' || strings.HasPrefix(content, "//"):
+			continue
+		case content[0] == '}':
+			if len(stack) != 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		eq := strings.IndexByte(content, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.Trim(strings.TrimRight(content[:eq], " \t"), `"`)
+		value := strings.TrimLeft(content[eq+1:], " \t")
+		start := lineStart + len(line) - len(value)
+		switch {
+		case value == "{":
+			stack = append(stack, pathFrame{Key: key})
+		case strings.HasPrefix(value, "["):
+			listDepth = strings.Count(value, "[") - strings.Count(value, "]")
+		case strings.HasPrefix(value, "<<"):
+			heredoc = strings.TrimPrefix(strings.TrimPrefix(value, "<<"), "-")
+		case strings.HasPrefix(value, `"`):
+			end := 1
+			for end < len(value) && value[end] != '"' {
+				if value[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(value) {
+				continue
+			}
+			values = append(values, structValue{
+				Path:  keyPath(append(stack, pathFrame{Key: key})),
+				Key:   key,
+				Value: value[1:end],
+				Start: start + 1,
+				End:   start + end,
+			})
+		}
+	}
+	return values
+}
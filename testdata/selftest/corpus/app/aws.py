@@ -0,0 +1,6 @@
+import boto3
+
+session = boto3.Session(
+    aws_access_key_id="AKIAZ7FAKEKEYID00000",
+    aws_secret_access_key="q8Zr3Jx0Lw7Vb2Nc5Tf9Hk1Pm4Ys6GdFakeKey00",
+)
@@ -0,0 +1,11 @@
+package db
+
+// dbPassword = "FakeDbPassw0rd1"
+
+/*
+func legacyClient() {
+	apiKey: "fakeapikey0123456789abc"
+}
+*/
+
+const url = "https://example.com/path" // not a comment marker inside the string
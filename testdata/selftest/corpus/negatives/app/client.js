@@ -0,0 +1 @@
+const apiKey = process.env.API_KEY_FROM_ENVIRONMENT;
@@ -0,0 +1,9 @@
+export const config = {
+  password: process.env.DB_PASSWORD,
+  apiKey: getApiKey(region),
+  secretName: "billing-api-secret-v2",
+  tokenUrl: "https://auth.example.com/oauth/token",
+  maxTokens: 4096,
+  adminPassword: ADMIN_PASSWORD_FROM_VAULT,
+  accessKey: settings.storage.accessKey,
+};
@@ -0,0 +1,5 @@
+package app
+
+const defaultPlaceholderIdentifier = "abcdefabcdefabcdefabcdefabcdef"
+
+func loadConfigurationFromEnvironment() {}
@@ -0,0 +1,5 @@
+import os
+
+api_key = os.environ["API_KEY"]
+client_secret = os.getenv("CLIENT_SECRET")
+password = ""
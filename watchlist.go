@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// watchlistRule is the rule name of findings of watchlisted secrets.
+const watchlistRule = "watchlist"
+
+// watchlist holds the hex SHA-256 hashes of known secrets loaded from
+// --watchlist. When non-nil, repos are checked only for these secrets.
+var watchlist map[string]bool
+
+// watchTokenPattern matches candidate secret values: runs of characters that
+// do not usually delimit a value in code or config.
+var watchTokenPattern = regexp.MustCompile("[^\\s\"'`<>(){}\\[\\],;]+")
+
+// loadWatchlist reads a newline-delimited list of hex SHA-256 hashes of
+// secrets. Blank lines and lines starting with '#' are ignored.
+func loadWatchlist(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make(map[string]bool)
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.ToLower(line)
+		if b, err := hex.DecodeString(line); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%s:%d: not a hex SHA-256 hash", path, n)
+		}
+		hashes[line] = true
+	}
+	return hashes, s.Err()
+}
+
+// findWatchlisted returns the positions of every candidate value in fileData
+// whose hash is in watchlist. Besides whole tokens, the parts of a token
+// separated by '=' or ':', and those parts split at '@', are tried, so values
+// in assignments and URL userinfo are found.
+func findWatchlisted(fileData []byte) (positions []SensitivePos) {
+	for _, loc := range watchTokenPattern.FindAllIndex(fileData, -1) {
+		candidates := [][2]int{{loc[0], loc[1]}}
+		for _, part := range splitRange(fileData, loc[0], loc[1], "=:") {
+			candidates = append(candidates, part)
+			candidates = append(candidates, splitRange(fileData, part[0], part[1], "@")...)
+		}
+		for _, c := range candidates {
+			sum := sha256.Sum256(fileData[c[0]:c[1]])
+			if watchlist[hex.EncodeToString(sum[:])] {
+				positions = append(positions, SensitivePos{
+					Start: c[0],
+					End:   c[1],
+					Rule:  watchlistRule,
+				})
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// splitRange returns the non-empty ranges of data[start:end] separated by any
+// byte in seps, or nil if no separator occurs.
+func splitRange(data []byte, start, end int, seps string) (parts [][2]int) {
+	partStart := start
+	for i := start; i < end; i++ {
+		if strings.IndexByte(seps, data[i]) < 0 {
+			continue
+		}
+		if i > partStart {
+			parts = append(parts, [2]int{partStart, i})
+		}
+		partStart = i + 1
+	}
+	if partStart == start {
+		return nil
+	}
+	if end > partStart {
+		parts = append(parts, [2]int{partStart, end})
+	}
+	return parts
+}
+
+// findWatchlistedHistory checks every file version reachable from any ref in
+// r for watchlisted secrets. Each distinct file version is checked once and
+// reported with the path and newest commit it was found at.
+func findWatchlistedHistory(r *git.Repository) (files []SensitiveFile, err error) {
+	iter, err := r.Log(&git.LogOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+		return tree.Files().ForEach(func(f *object.File) error {
+			if seen[f.Hash] {
+				return nil
+			}
+			seen[f.Hash] = true
+
+			contents, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			if positions := findWatchlisted([]byte(contents)); positions != nil {
+				captureContext(positions, []byte(contents))
+				files = append(files, SensitiveFile{
+					Path:      f.Name,
+					Commit:    c.Hash.String(),
+					Positions: positions,
+				})
+			}
+			return nil
+		})
+	})
+	return files, err
+}